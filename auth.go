@@ -5,12 +5,17 @@ package Intouchpay
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
 	"time"
 )
 
 // Credentials represents authentication data for a single API request
 type Credentials struct {
 	Username  string
+	AccountNo string // Account number the password was hashed with, empty if unknown
 	Timestamp string
 	Password  string
 }
@@ -21,33 +26,79 @@ type Authenticator interface {
 	Authenticate() Credentials
 }
 
+// ErrNoCredentials is returned, wrapped with the cause, when a request cannot be built
+// because the credential provider failed and has never supplied credentials
+var ErrNoCredentials = errors.New("no credentials available")
+
+// fallibleAuthenticator is implemented by authenticators that can report why they have no
+// credentials instead of returning blank ones
+type fallibleAuthenticator interface {
+	authenticate() (Credentials, error)
+}
+
 // sha256Auth implements Authenticator using SHA256 hashing
 type sha256Auth struct {
-	username        string
-	accountNo       string
-	partnerPassword string
+	provider CredentialProvider
+	mu       sync.Mutex
+	last     AccountCredentials // Last credentials read successfully
+	loaded   bool               // Whether last has been set
 }
 
 // NewAuthenticator creates the default authenticator with standard SHA256 hashing
 func NewAuthenticator(username, accountNo, partnerPassword string) Authenticator {
-	return &sha256Auth{
-		username:        username,
-		accountNo:       accountNo,
-		partnerPassword: partnerPassword,
-	}
+	return NewAuthenticatorWithProvider(StaticCredentials(username, accountNo, partnerPassword))
+}
+
+// NewAuthenticatorWithProvider creates the default authenticator reading its credentials from
+// provider on every call. When the provider fails, the last credentials it returned are used.
+func NewAuthenticatorWithProvider(provider CredentialProvider) Authenticator {
+	return &sha256Auth{provider: provider}
 }
 
-// Authenticate generates credentials for a single API call
+// Authenticate generates credentials for a single API call. When the provider has never
+// supplied credentials, the result is blank; the Client methods refuse to send a request in
+// that case.
 func (a *sha256Auth) Authenticate() Credentials {
+	creds, err := a.authenticate()
+	if err != nil {
+		log.Printf("warning: %v", err)
+	}
+	return creds
+}
+
+// authenticate generates credentials for a single API call, or returns an error wrapping
+// ErrNoCredentials when the provider has never supplied credentials
+func (a *sha256Auth) authenticate() (Credentials, error) {
+	creds, err := a.credentials()
+	if err != nil {
+		return Credentials{}, err
+	}
 	now := time.Now().UTC()
 	timestamp := now.Format("20060102150405")
-	passwordString := a.username + a.accountNo + a.partnerPassword + timestamp
+	passwordString := creds.Username + creds.AccountNo + creds.PartnerPassword + timestamp
 	hash := sha256.Sum256([]byte(passwordString))
 	password := hex.EncodeToString(hash[:])
 
 	return Credentials{
-		Username:  a.username,
+		Username:  creds.Username,
+		AccountNo: creds.AccountNo,
 		Timestamp: timestamp,
 		Password:  password,
+	}, nil
+}
+
+// credentials reads the provider, falling back to the last good credentials on error
+func (a *sha256Auth) credentials() (AccountCredentials, error) {
+	creds, err := a.provider.Credentials()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err != nil {
+		if !a.loaded {
+			return AccountCredentials{}, fmt.Errorf("%w: %w", ErrNoCredentials, err)
+		}
+		log.Printf("warning: failed to load credentials, using the last known ones: %v", err)
+		return a.last, nil
 	}
+	a.last, a.loaded = creds, true
+	return creds, nil
 }
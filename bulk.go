@@ -0,0 +1,286 @@
+package Intouchpay
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BulkDepositStatus is the outcome of one deposit in a bulk run
+type BulkDepositStatus string
+
+// Bulk deposit statuses
+const (
+	BulkStatusSucceeded BulkDepositStatus = "succeeded"
+	BulkStatusFailed    BulkDepositStatus = "failed"  // The API answered with a failure code
+	BulkStatusUnknown   BulkDepositStatus = "unknown" // The request failed without an answer; the money may have moved
+	BulkStatusSkipped   BulkDepositStatus = "skipped" // Not sent because the run stopped early
+)
+
+// BulkDepositOptions configures BulkDeposit
+type BulkDepositOptions struct {
+	Concurrency      int  // Deposits sent in parallel, 1 if zero
+	FailureThreshold int  // Stop after this many failed or unknown deposits; 0 never stops
+	SkipBalanceCheck bool // Do not compare the balance with the batch total before starting
+	// Resume is the report of an earlier run over the same items. Succeeded items and items
+	// that failed with a non-retryable code are carried over, unknown items are resolved with
+	// GetTransactionStatus and everything else is sent again. IntouchPay refuses a request
+	// transaction ID it has already seen, so items that failed with a retryable code are sent
+	// under a new ID, the item's ID with a -r<n> suffix.
+	Resume *BulkDepositReport
+}
+
+// BulkDepositResult is the outcome of one deposit in a bulk run
+type BulkDepositResult struct {
+	RequestTransactionID string            `json:"requesttransactionid"`        // The item's ID
+	SentTransactionID    string            `json:"senttransactionid,omitempty"` // ID of the last send, see Retries
+	Retries              int               `json:"retries,omitempty"`           // Sends under a new ID after a retryable failure
+	Amount               Money             `json:"amount"`
+	MobilePhone          string            `json:"mobilephone"`
+	Status               BulkDepositStatus `json:"status"`
+	ReferenceID          string            `json:"referenceid,omitempty"`
+	ResponseCode         ResponseCode      `json:"responsecode,omitempty"`
+	Error                string            `json:"error,omitempty"`
+}
+
+// BulkDepositReport is the per-item report of a bulk run. It is JSON-serializable so a run
+// can be resumed from a saved copy.
+type BulkDepositReport struct {
+	StartedAt   time.Time           `json:"startedat"`
+	FinishedAt  time.Time           `json:"finishedat"`
+	Total       Money               `json:"total"` // Sum of all item amounts
+	Succeeded   int                 `json:"succeeded"`
+	Failed      int                 `json:"failed"`
+	Unknown     int                 `json:"unknown"`
+	Skipped     int                 `json:"skipped"`
+	Aborted     bool                `json:"aborted"`
+	AbortReason string              `json:"abortreason,omitempty"`
+	Results     []BulkDepositResult `json:"results"` // In the same order as the items
+}
+
+// BulkDeposit sends a batch of deposits with bounded concurrency and returns a per-item report.
+// The client's Sid is used for every deposit; configure it with WithSid(1) for bulk payments.
+// Items must have unique request transaction IDs. When ctx is cancelled the remaining items
+// are skipped and the partial report is returned together with ctx.Err().
+func (c *Client) BulkDeposit(ctx context.Context, items []RequestDepositParams, opts *BulkDepositOptions) (*BulkDepositReport, error) {
+	o := BulkDepositOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 1
+	}
+
+	report := &BulkDepositReport{
+		StartedAt: time.Now().UTC(),
+		Results:   make([]BulkDepositResult, len(items)),
+	}
+	previous, err := previousResults(o.Resume)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(items))
+	var pending []int
+	var pendingTotal Money
+	for i, item := range items {
+		if item.RequestTransactionID == "" {
+			return nil, newValidationError("requesttransactionid", fmt.Sprintf("item %d has no request transaction ID", i))
+		}
+		if seen[item.RequestTransactionID] {
+			return nil, newValidationError("requesttransactionid", fmt.Sprintf("duplicate request transaction ID %q", item.RequestTransactionID))
+		}
+		seen[item.RequestTransactionID] = true
+		report.Total = report.Total.Add(item.Amount)
+
+		result := BulkDepositResult{
+			RequestTransactionID: item.RequestTransactionID,
+			Amount:               item.Amount,
+			MobilePhone:          item.MobilePhone,
+			Status:               BulkStatusSkipped,
+		}
+		if prev, ok := previous[item.RequestTransactionID]; ok {
+			result = c.resumeResult(ctx, prev)
+		}
+		report.Results[i] = result
+		if needsSending(result) {
+			pending = append(pending, i)
+			pendingTotal = pendingTotal.Add(item.Amount)
+		}
+	}
+
+	if !o.SkipBalanceCheck && len(pending) > 0 {
+		balance, err := c.GetBalanceContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !balance.Success {
+			return nil, newValidationError("balance", fmt.Sprintf("balance query failed with code %s", balance.Code()))
+		}
+		if balance.Balance.Cmp(pendingTotal) < 0 {
+			return nil, newValidationError("items", fmt.Sprintf("batch total %s exceeds balance %s", pendingTotal, balance.Balance))
+		}
+	}
+
+	c.runBulk(ctx, items, pending, o, report)
+
+	report.FinishedAt = time.Now().UTC()
+	for _, result := range report.Results {
+		switch result.Status {
+		case BulkStatusSucceeded:
+			report.Succeeded++
+		case BulkStatusFailed:
+			report.Failed++
+		case BulkStatusUnknown:
+			report.Unknown++
+		default:
+			report.Skipped++
+		}
+	}
+	if ctx.Err() != nil {
+		report.Aborted = true
+		report.AbortReason = ctx.Err().Error()
+		return report, ctx.Err()
+	}
+	return report, nil
+}
+
+// runBulk sends the pending items with o.Concurrency workers, filling in report.Results
+func (c *Client) runBulk(ctx context.Context, items []RequestDepositParams, pending []int, o BulkDepositOptions, report *BulkDepositReport) {
+	var mu sync.Mutex
+	failures := 0
+	stopped := false
+
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < o.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				mu.Lock()
+				stop := stopped
+				mu.Unlock()
+				if stop || ctx.Err() != nil {
+					continue
+				}
+				result := c.sendBulkItem(ctx, items[i], report.Results[i])
+
+				mu.Lock()
+				report.Results[i] = result
+				if result.Status == BulkStatusFailed || result.Status == BulkStatusUnknown {
+					failures++
+					if o.FailureThreshold > 0 && failures >= o.FailureThreshold && !stopped {
+						stopped = true
+						report.Aborted = true
+						report.AbortReason = fmt.Sprintf("failure threshold of %d reached", o.FailureThreshold)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, i := range pending {
+		mu.Lock()
+		stop := stopped
+		mu.Unlock()
+		if stop || ctx.Err() != nil {
+			break
+		}
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// sendBulkItem sends one deposit and converts the outcome into a result. An item that failed
+// with a retryable code was seen by IntouchPay, so it is sent again under a new ID.
+func (c *Client) sendBulkItem(ctx context.Context, item RequestDepositParams, result BulkDepositResult) BulkDepositResult {
+	if result.Status == BulkStatusFailed && result.ResponseCode.Class() == ClassRetryableFailure {
+		result.Retries++
+	}
+	params := item
+	if result.Retries > 0 {
+		params.RequestTransactionID = fmt.Sprintf("%s-r%d", item.RequestTransactionID, result.Retries)
+	}
+	result.SentTransactionID = params.RequestTransactionID
+	result.ResponseCode = ""
+	result.ReferenceID = ""
+	resp, err := c.RequestDepositContext(ctx, &params)
+	result.Error = ""
+	if err != nil {
+		result.Error = err.Error()
+		if IsValidationError(err) || IsRequestNotSent(err) {
+			result.Status = BulkStatusFailed
+		} else {
+			result.Status = BulkStatusUnknown
+		}
+		return result
+	}
+
+	result.ResponseCode = resp.Code()
+	result.ReferenceID = resp.ReferenceID
+	if resp.Success || resp.Code().Class() == ClassSuccess {
+		result.Status = BulkStatusSucceeded
+	} else {
+		result.Status = BulkStatusFailed
+	}
+	return result
+}
+
+// resumeResult brings a result from an earlier run up to date. Unknown results are checked
+// with GetTransactionStatus so a deposit that already went through is not sent twice.
+func (c *Client) resumeResult(ctx context.Context, prev BulkDepositResult) BulkDepositResult {
+	if prev.Status != BulkStatusUnknown {
+		return prev
+	}
+	sent := prev.SentTransactionID
+	if sent == "" {
+		sent = prev.RequestTransactionID
+	}
+	status, err := c.GetTransactionStatusContext(ctx, &GetTransactionStatusParams{
+		RequestTransactionID: sent,
+	})
+	if err != nil {
+		return prev
+	}
+	switch code := status.Code(); {
+	case code.Class() == ClassSuccess:
+		prev.Status = BulkStatusSucceeded
+		prev.ResponseCode = code
+		prev.Error = ""
+	case code == CodeTransactionNotFound:
+		prev.Status = BulkStatusSkipped
+		prev.Error = ""
+	}
+	return prev
+}
+
+// needsSending reports whether a result still has to be sent
+func needsSending(result BulkDepositResult) bool {
+	switch result.Status {
+	case BulkStatusSkipped:
+		return true
+	case BulkStatusFailed:
+		return result.ResponseCode == "" || result.ResponseCode.Class() == ClassRetryableFailure
+	default:
+		return false
+	}
+}
+
+// previousResults indexes the results of a report by request transaction ID
+func previousResults(report *BulkDepositReport) (map[string]BulkDepositResult, error) {
+	results := make(map[string]BulkDepositResult)
+	if report == nil {
+		return results, nil
+	}
+	for _, result := range report.Results {
+		if _, ok := results[result.RequestTransactionID]; ok {
+			return nil, newValidationError("resume", fmt.Sprintf("duplicate request transaction ID %q in report", result.RequestTransactionID))
+		}
+		results[result.RequestTransactionID] = result
+	}
+	return results, nil
+}
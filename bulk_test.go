@@ -0,0 +1,143 @@
+package Intouchpay_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+	"github.com/samueltuyizere/go-intouchpay/intouchpaytest"
+	"github.com/stretchr/testify/assert"
+)
+
+// bulkItems returns n deposits of francs each with IDs B0..Bn-1
+func bulkItems(n int, francs int64) []Intouchpay.RequestDepositParams {
+	items := make([]Intouchpay.RequestDepositParams, n)
+	for i := range items {
+		items[i] = Intouchpay.RequestDepositParams{
+			Amount:               Intouchpay.RWF(francs),
+			MobilePhone:          "0781234567",
+			Reason:               "payout",
+			RequestTransactionID: fmt.Sprintf("B%d", i),
+		}
+	}
+	return items
+}
+
+// TestBulkDepositSuccess tests a concurrent run where every deposit succeeds
+func TestBulkDepositSuccess(t *testing.T) {
+	server := intouchpaytest.NewServer(intouchpaytest.WithBalance(Intouchpay.RWF(10000)))
+	defer server.Close()
+	client := server.NewClient(Intouchpay.WithSid(1))
+
+	report, err := client.BulkDeposit(context.Background(), bulkItems(8, 100), &Intouchpay.BulkDepositOptions{Concurrency: 3})
+
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.RWF(800), report.Total)
+	assert.Equal(t, 8, report.Succeeded)
+	assert.False(t, report.Aborted)
+	for _, result := range report.Results {
+		assert.Equal(t, Intouchpay.BulkStatusSucceeded, result.Status)
+		assert.NotEmpty(t, result.ReferenceID)
+	}
+	assert.Equal(t, Intouchpay.RWF(9200), server.Balance())
+}
+
+// TestBulkDepositBalanceCheck tests that a batch above the balance is refused before sending
+func TestBulkDepositBalanceCheck(t *testing.T) {
+	server := intouchpaytest.NewServer(intouchpaytest.WithBalance(Intouchpay.RWF(500)))
+	defer server.Close()
+	client := server.NewClient()
+
+	report, err := client.BulkDeposit(context.Background(), bulkItems(6, 100), nil)
+
+	assert.Nil(t, report)
+	assert.True(t, Intouchpay.IsValidationError(err))
+	assert.Empty(t, server.Transactions())
+}
+
+// TestBulkDepositFailureThresholdAndResume tests stopping on failures and resuming from the report
+func TestBulkDepositFailureThresholdAndResume(t *testing.T) {
+	server := intouchpaytest.NewServer(intouchpaytest.WithBalance(Intouchpay.RWF(10000)))
+	defer server.Close()
+	client := server.NewClient()
+	limitReached := true
+	server.OnRequest(Intouchpay.RequestDepositEndpoint, func(req *intouchpaytest.Request) *intouchpaytest.Outcome {
+		if limitReached && req.RequestTransactionID() != "B0" {
+			return &intouchpaytest.Outcome{Code: Intouchpay.CodeDailyLimitExceeded}
+		}
+		return nil
+	})
+	items := bulkItems(5, 100)
+
+	report, err := client.BulkDeposit(context.Background(), items, &Intouchpay.BulkDepositOptions{FailureThreshold: 2})
+
+	assert.NoError(t, err)
+	assert.True(t, report.Aborted)
+	assert.Equal(t, 1, report.Succeeded)
+	assert.Equal(t, 2, report.Failed)
+	assert.Equal(t, 2, report.Skipped)
+	assert.Equal(t, Intouchpay.CodeDailyLimitExceeded, report.Results[1].ResponseCode)
+
+	limitReached = false
+	resumed, err := client.BulkDeposit(context.Background(), items, &Intouchpay.BulkDepositOptions{Resume: report})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, resumed.Succeeded)
+	assert.Equal(t, report.Results[0].ReferenceID, resumed.Results[0].ReferenceID)
+	assert.Len(t, server.Transactions(), 5)
+}
+
+// TestBulkDepositResumeUsesNewIDs tests that items refused with a retryable code are sent again
+// under a new ID, since IntouchPay answers 1110 for an ID it has already seen
+func TestBulkDepositResumeUsesNewIDs(t *testing.T) {
+	server := intouchpaytest.NewServer(intouchpaytest.WithBalance(Intouchpay.RWF(10000)))
+	defer server.Close()
+	client := server.NewClient()
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	limitReached := true
+	server.OnRequest(Intouchpay.RequestDepositEndpoint, func(req *intouchpaytest.Request) *intouchpaytest.Outcome {
+		mu.Lock()
+		defer mu.Unlock()
+		id := req.RequestTransactionID()
+		if seen[id] {
+			return &intouchpaytest.Outcome{Code: Intouchpay.CodeDuplicateRemitID}
+		}
+		seen[id] = true
+		if limitReached {
+			return &intouchpaytest.Outcome{Code: Intouchpay.CodeDailyLimitExceeded}
+		}
+		return nil
+	})
+	items := bulkItems(2, 100)
+
+	report, err := client.BulkDeposit(context.Background(), items, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Failed)
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		limitReached = attempt == 1
+		report, err = client.BulkDeposit(context.Background(), items, &Intouchpay.BulkDepositOptions{Resume: report})
+		assert.NoError(t, err)
+		for i, result := range report.Results {
+			assert.Equal(t, items[i].RequestTransactionID, result.RequestTransactionID)
+			assert.Equal(t, fmt.Sprintf("%s-r%d", items[i].RequestTransactionID, attempt), result.SentTransactionID)
+			assert.Equal(t, attempt, result.Retries)
+		}
+	}
+	assert.Equal(t, 2, report.Succeeded)
+	assert.Len(t, server.Transactions(), 2)
+}
+
+// TestBulkDepositRejectsDuplicateIDs tests input validation
+func TestBulkDepositRejectsDuplicateIDs(t *testing.T) {
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, &MockHTTPClient{})
+	items := bulkItems(2, 100)
+	items[1].RequestTransactionID = items[0].RequestTransactionID
+
+	_, err := client.BulkDeposit(context.Background(), items, &Intouchpay.BulkDepositOptions{SkipBalanceCheck: true})
+
+	assert.True(t, Intouchpay.IsValidationError(err))
+}
@@ -0,0 +1,86 @@
+package Intouchpay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+)
+
+// endpointPattern matches endpoint paths in the form of the documented ones, such as /getbalance/
+var endpointPattern = regexp.MustCompile(`^/[A-Za-z0-9_-]+(/[A-Za-z0-9_-]+)*/$`)
+
+// Call sends params to endpoint and decodes the response into out. It is meant for endpoints
+// and fields the library does not support yet.
+//
+// params is encoded as a JSON object (a struct, a map or nil) and the username, timestamp and
+// password are added from the Authenticator, replacing any given in params. The client's
+// account number is added as accountno unless params sets it. The call goes through the same
+// pipeline as the other methods, with retries, rate limiting, logging, metrics and tracing.
+//
+// endpoint is a path relative to the base URL with a leading and a trailing slash, such as
+// "/getcommission/". out must be a non-nil pointer, such as *map[string]interface{} or a pointer to a struct.
+func (c *Client) Call(ctx context.Context, endpoint string, params, out interface{}) error {
+	ctx, span := c.startSpan(ctx, "Call", endpoint)
+	err := c.call(ctx, endpoint, params, out)
+	code := ResponseCode("")
+	if err == nil {
+		code, _, _ = responseSummary(out)
+	}
+	endSpan(span, code, err)
+	return err
+}
+
+// call validates the arguments, builds the request body and sends it
+func (c *Client) call(ctx context.Context, endpoint string, params, out interface{}) error {
+	if endpoint == "" {
+		return newValidationError("endpoint", "endpoint is required")
+	}
+	if !endpointPattern.MatchString(endpoint) {
+		return newValidationError("endpoint", fmt.Sprintf("endpoint must be a path such as /getbalance/, got %q", endpoint))
+	}
+	if v := reflect.ValueOf(out); v.Kind() != reflect.Pointer || v.IsNil() {
+		return newValidationError("out", fmt.Sprintf("out must be a non-nil pointer, got %T", out))
+	}
+
+	body, err := callBody(params)
+	if err != nil {
+		return err
+	}
+	creds, err := c.credentials()
+	if err != nil {
+		return err
+	}
+	body["username"] = creds.Username
+	body["timestamp"] = creds.Timestamp
+	body["password"] = creds.Password
+	if _, ok := body["accountno"]; !ok && creds.AccountNo != "" {
+		body["accountno"] = creds.AccountNo
+	}
+
+	return c.requester().DoInto(ctx, endpoint, body, out)
+}
+
+// callBody encodes params as a JSON object. Numbers are kept as json.Number so large values
+// are sent unchanged.
+func callBody(params interface{}) (map[string]interface{}, error) {
+	body := make(map[string]interface{})
+	if params == nil {
+		return body, nil
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, NewMarshalError("params", err)
+	}
+	if bytes.Equal(data, []byte("null")) {
+		return body, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&body); err != nil {
+		return nil, newValidationError("params", fmt.Sprintf("params must encode to a JSON object, got %T", params))
+	}
+	return body, nil
+}
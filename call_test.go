@@ -0,0 +1,99 @@
+package Intouchpay_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+	"github.com/samueltuyizere/go-intouchpay/intouchpaytest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCallInjectsCredentials tests that Call sends params with the credentials and account number
+func TestCallInjectsCredentials(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/getcommission/", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(`{"success":true,"responsecode":"01","commission":12.5}`))
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	auth := &MockAuthenticator{Creds: Intouchpay.Credentials{Username: "test_user", Timestamp: "20260320120000", Password: "test_hash"}}
+	client := Intouchpay.NewClientWithHTTPClient(auth, Intouchpay.NewHTTPClient(&http.Client{Timeout: 5 * time.Second}, server.URL))
+	client.AccountNo = "ACC123"
+
+	params := struct {
+		Amount   Intouchpay.Money `json:"amount"`
+		Password string           `json:"password"`
+	}{Amount: Intouchpay.RWF(9007199254740), Password: "ignored"}
+	var out struct {
+		Success    bool                    `json:"success"`
+		Code       Intouchpay.ResponseCode `json:"responsecode"`
+		Commission Intouchpay.Money        `json:"commission"`
+	}
+	assert.NoError(t, client.Call(context.Background(), "/getcommission/", params, &out))
+
+	assert.Equal(t, "test_user", received["username"])
+	assert.Equal(t, "20260320120000", received["timestamp"])
+	assert.Equal(t, "test_hash", received["password"])
+	assert.Equal(t, "ACC123", received["accountno"])
+	assert.Equal(t, 9007199254740.0, received["amount"])
+	assert.True(t, out.Success)
+	assert.Equal(t, Intouchpay.CodeSuccessful, out.Code)
+	assert.Equal(t, Intouchpay.MinorUnits(1250), out.Commission)
+
+	// An explicit account number is kept
+	assert.NoError(t, client.Call(context.Background(), "/getcommission/", map[string]interface{}{"accountno": "OTHER"}, &out))
+	assert.Equal(t, "OTHER", received["accountno"])
+}
+
+// TestCallRejectsMalformedEndpoints tests that endpoints must be paths like the documented ones
+func TestCallRejectsMalformedEndpoints(t *testing.T) {
+	mock := &MockHTTPClient{Response: &map[string]interface{}{"success": true}}
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, mock)
+	var out map[string]interface{}
+
+	for _, endpoint := range []string{"", " ", "getbalance", "/getbalance", "getbalance/", "//", "/get balance/", "/../", "https://example.com/x/", "/a?b=c/"} {
+		err := client.Call(context.Background(), endpoint, nil, &out)
+		assert.True(t, Intouchpay.IsValidationError(err), endpoint)
+	}
+	assert.False(t, mock.Called)
+	assert.NoError(t, client.Call(context.Background(), "/v2/getcommission/", nil, &out))
+}
+
+// TestCallWithFakeServer tests Call against a known endpoint through the normal pipeline
+func TestCallWithFakeServer(t *testing.T) {
+	server := intouchpaytest.NewServer(intouchpaytest.WithBalance(Intouchpay.RWF(2500)))
+	defer server.Close()
+	client := server.NewClient(Intouchpay.WithRetryPolicy(Intouchpay.DefaultRetryPolicy()))
+
+	var resp Intouchpay.BalanceResponse
+	assert.NoError(t, client.Call(context.Background(), Intouchpay.GetBalanceEndpoint, nil, &resp))
+	assert.True(t, resp.Success)
+	assert.Equal(t, Intouchpay.RWF(2500), resp.Balance)
+
+	var raw map[string]interface{}
+	assert.NoError(t, client.Call(context.Background(), Intouchpay.GetBalanceEndpoint, nil, &raw))
+	assert.Equal(t, true, raw["success"])
+}
+
+// TestCallValidation tests that bad arguments are refused before any call is made
+func TestCallValidation(t *testing.T) {
+	mock := &MockHTTPClient{Response: &map[string]interface{}{"success": true}}
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, mock)
+	var out map[string]interface{}
+
+	assert.True(t, Intouchpay.IsValidationError(client.Call(context.Background(), "", nil, &out)))
+	assert.True(t, Intouchpay.IsValidationError(client.Call(context.Background(), "/x/", nil, out)))
+	assert.True(t, Intouchpay.IsValidationError(client.Call(context.Background(), "/x/", nil, nil)))
+	assert.True(t, Intouchpay.IsValidationError(client.Call(context.Background(), "/x/", []string{"a"}, &out)))
+	assert.True(t, Intouchpay.IsMarshalError(client.Call(context.Background(), "/x/", map[string]interface{}{"f": func() {}}, &out)))
+	assert.False(t, mock.Called)
+}
@@ -0,0 +1,170 @@
+package Intouchpay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+)
+
+// DefaultCallbackMaxBodySize is the largest callback body the CallbackHandler accepts
+const DefaultCallbackMaxBodySize int64 = 64 << 10
+
+// CallbackEvent represents a transaction status notification sent by IntouchPay to the callback URL
+type CallbackEvent struct {
+	RequestTransactionID string       `json:"requesttransactionid"`
+	TransactionID        string       `json:"transactionid"`
+	ResponseCode         ResponseCode `json:"responsecode"`
+	Status               string       `json:"status"`
+	StatusDesc           string       `json:"statusdesc"`
+	ReferenceNo          string       `json:"referenceno"`
+
+	ResponseMeta
+}
+
+// Code returns the typed response code
+func (e *CallbackEvent) Code() ResponseCode {
+	return e.ResponseCode.canonical()
+}
+
+// UnmarshalJSON decodes the event, keeping the raw body and unknown fields
+func (e *CallbackEvent) UnmarshalJSON(data []byte) error {
+	type plain CallbackEvent
+	return decodeResponse(data, (*plain)(e), &e.ResponseMeta)
+}
+
+// callbackEnvelope is the wrapper IntouchPay sends callback events in
+type callbackEnvelope struct {
+	JSONPayload *CallbackEvent `json:"jsonpayload"`
+}
+
+// CallbackAck is the acknowledgement body written back to IntouchPay
+type CallbackAck struct {
+	Message   string `json:"message"`
+	Success   bool   `json:"success"`
+	RequestID string `json:"request_id"`
+}
+
+// CallbackFunc processes a decoded callback event.
+// Returning an error makes the handler answer with 500 so IntouchPay can deliver the event again.
+type CallbackFunc func(ctx context.Context, event *CallbackEvent) error
+
+// CallbackHandler is an http.Handler that receives IntouchPay callbacks
+type CallbackHandler struct {
+	fn          CallbackFunc
+	maxBodySize int64
+	tracer      Tracer
+	traceLinks  *TraceLinks
+}
+
+// CallbackOption configures a CallbackHandler
+type CallbackOption func(*CallbackHandler)
+
+// WithCallbackMaxBodySize sets the largest callback body the handler accepts
+func WithCallbackMaxBodySize(size int64) CallbackOption {
+	return func(h *CallbackHandler) {
+		h.maxBodySize = size
+	}
+}
+
+// WithCallbackTracer processes every callback in a span. When links is shared with a client
+// created with WithTracer, the span joins the trace of the payment it reports on.
+func WithCallbackTracer(tracer Tracer, links *TraceLinks) CallbackOption {
+	return func(h *CallbackHandler) {
+		h.tracer = tracer
+		h.traceLinks = links
+	}
+}
+
+// NewCallbackHandler creates a CallbackHandler that calls fn for every valid callback
+func NewCallbackHandler(fn CallbackFunc, opts ...CallbackOption) *CallbackHandler {
+	h := &CallbackHandler{
+		fn:          fn,
+		maxBodySize: DefaultCallbackMaxBodySize,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// DecodeCallback decodes a callback body in the jsonpayload envelope IntouchPay uses
+func DecodeCallback(r io.Reader) (*CallbackEvent, error) {
+	var envelope callbackEnvelope
+	if err := json.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, NewMarshalError("callback payload", err)
+	}
+	if envelope.JSONPayload == nil {
+		return nil, newValidationError("jsonpayload", "missing callback payload")
+	}
+	if envelope.JSONPayload.RequestTransactionID == "" {
+		return nil, newValidationError("requesttransactionid", "missing request transaction ID")
+	}
+	return envelope.JSONPayload, nil
+}
+
+// ServeHTTP decodes the callback, passes it to the CallbackFunc and acknowledges it
+func (h *CallbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeCallbackAck(w, http.StatusMethodNotAllowed, CallbackAck{Message: "method not allowed"})
+		return
+	}
+
+	event, err := DecodeCallback(http.MaxBytesReader(w, r.Body, h.maxBodySize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeCallbackAck(w, http.StatusRequestEntityTooLarge, CallbackAck{Message: "payload too large"})
+			return
+		}
+		writeCallbackAck(w, http.StatusBadRequest, CallbackAck{Message: err.Error()})
+		return
+	}
+
+	if err := h.process(r.Context(), event); err != nil {
+		writeCallbackAck(w, http.StatusInternalServerError, CallbackAck{
+			Message:   "failed to process callback",
+			RequestID: event.RequestTransactionID,
+		})
+		return
+	}
+
+	writeCallbackAck(w, http.StatusOK, CallbackAck{
+		Message:   "success",
+		Success:   true,
+		RequestID: event.RequestTransactionID,
+	})
+}
+
+// process calls the CallbackFunc, in a span when a tracer is configured
+func (h *CallbackHandler) process(ctx context.Context, event *CallbackEvent) error {
+	if h.tracer == nil {
+		return h.fn(ctx, event)
+	}
+	parent := ctx
+	if linked, ok := h.traceLinks.lookup(event.RequestTransactionID); ok {
+		parent = linked
+	}
+	spanCtx, span := h.tracer.Start(parent, "intouchpay.Callback",
+		Attribute{Key: AttrRequestTransactionID, Value: event.RequestTransactionID},
+	)
+	if parent != ctx {
+		// Keep the span from the payment's trace but the cancellation of this request
+		spanCtx = linkedContext{Context: ctx, values: spanCtx}
+	}
+	err := h.fn(spanCtx, event)
+	endSpan(span, event.Code(), err)
+	return err
+}
+
+// writeCallbackAck writes ack as a JSON response with the given status code
+func writeCallbackAck(w http.ResponseWriter, statusCode int, ack CallbackAck) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(ack); err != nil {
+		log.Printf("warning: failed to write callback acknowledgement: %v", err)
+	}
+}
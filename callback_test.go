@@ -0,0 +1,92 @@
+package Intouchpay_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+	"github.com/stretchr/testify/assert"
+)
+
+const callbackBody = `{"jsonpayload":{"requesttransactionid":"TX123","transactionid":"1425","responsecode":"01","status":"Successfull","statusdesc":"Successfully Processed Transaction","referenceno":"312333883"}}`
+
+// TestCallbackHandlerSuccess tests that a valid callback is decoded and acknowledged
+func TestCallbackHandlerSuccess(t *testing.T) {
+	var received *Intouchpay.CallbackEvent
+	handler := Intouchpay.NewCallbackHandler(func(_ context.Context, event *Intouchpay.CallbackEvent) error {
+		received = event
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/callback", strings.NewReader(callbackBody))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotNil(t, received)
+	assert.Equal(t, "TX123", received.RequestTransactionID)
+	assert.Equal(t, "1425", received.TransactionID)
+	assert.Equal(t, Intouchpay.CodeSuccessful, received.ResponseCode)
+	assert.Equal(t, "312333883", received.ReferenceNo)
+
+	var ack Intouchpay.CallbackAck
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&ack))
+	assert.True(t, ack.Success)
+	assert.Equal(t, "success", ack.Message)
+	assert.Equal(t, "TX123", ack.RequestID)
+}
+
+// TestCallbackHandlerRejectsMalformedInput tests the 4xx responses for bad requests
+func TestCallbackHandlerRejectsMalformedInput(t *testing.T) {
+	called := false
+	handler := Intouchpay.NewCallbackHandler(func(_ context.Context, _ *Intouchpay.CallbackEvent) error {
+		called = true
+		return nil
+	}, Intouchpay.WithCallbackMaxBodySize(512))
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		code   int
+	}{
+		{"wrong method", http.MethodGet, "", http.StatusMethodNotAllowed},
+		{"invalid json", http.MethodPost, "{not json", http.StatusBadRequest},
+		{"missing envelope", http.MethodPost, `{"requesttransactionid":"TX123"}`, http.StatusBadRequest},
+		{"missing request id", http.MethodPost, `{"jsonpayload":{"status":"Successfull"}}`, http.StatusBadRequest},
+		{"too large", http.MethodPost, `{"jsonpayload":{"statusdesc":"` + strings.Repeat("x", 1024) + `"}}`, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/callback", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.code, rec.Code)
+			var ack Intouchpay.CallbackAck
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&ack))
+			assert.False(t, ack.Success)
+		})
+	}
+	assert.False(t, called)
+}
+
+// TestCallbackHandlerFuncError tests that processing failures are reported as 500
+func TestCallbackHandlerFuncError(t *testing.T) {
+	handler := Intouchpay.NewCallbackHandler(func(_ context.Context, _ *Intouchpay.CallbackEvent) error {
+		return errors.New("database unavailable")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/callback", strings.NewReader(callbackBody))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "database unavailable")
+}
@@ -0,0 +1,244 @@
+package Intouchpay
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting the API while the circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of a CircuitBreaker
+type CircuitState int
+
+// Circuit states
+const (
+	CircuitClosed   CircuitState = iota // Calls go through
+	CircuitOpen                         // Calls fail fast with ErrCircuitOpen
+	CircuitHalfOpen                     // A GetBalance probe is checking whether the API is back
+)
+
+// String returns the state name
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerOptions configures a CircuitBreaker
+type CircuitBreakerOptions struct {
+	FailureThreshold int           // Consecutive failures that open the circuit, 5 if zero
+	OpenTimeout      time.Duration // Time the circuit stays open before a probe, 30s if zero
+	// IsFailure decides whether an error counts as a failure. DefaultCircuitFailure is used when nil.
+	IsFailure func(err error) bool
+	// OnStateChange is called after every state change, outside the breaker's lock
+	OnStateChange func(from, to CircuitState)
+}
+
+// DefaultCircuitFailure counts network errors, timeouts and 5xx responses as failures.
+// Any answer from the API, even a failed one, shows that it is up, and a call the caller
+// cancelled says nothing about it.
+func DefaultCircuitFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var limitErr *RateLimitError
+	if errors.As(err, &limitErr) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// CircuitBreaker stops calls to the API after consecutive failures so callers do not wait for
+// timeouts while it is down. After OpenTimeout the next call first sends a GetBalance probe;
+// the circuit closes if the probe gets an answer and opens again otherwise.
+// A CircuitBreaker may be shared by several clients.
+type CircuitBreaker struct {
+	mu       sync.Mutex
+	opts     CircuitBreakerOptions
+	state    CircuitState
+	failures int
+	openedAt time.Time
+}
+
+// NewCircuitBreaker creates a closed CircuitBreaker. opts may be nil.
+func NewCircuitBreaker(opts *CircuitBreakerOptions) *CircuitBreaker {
+	o := CircuitBreakerOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.FailureThreshold <= 0 {
+		o.FailureThreshold = 5
+	}
+	if o.OpenTimeout <= 0 {
+		o.OpenTimeout = 30 * time.Second
+	}
+	if o.IsFailure == nil {
+		o.IsFailure = DefaultCircuitFailure
+	}
+	return &CircuitBreaker{opts: o}
+}
+
+// State returns the current state
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// allow reports whether a call may go ahead and whether it has to probe first
+func (b *CircuitBreaker) allow() (probe bool, err error) {
+	b.mu.Lock()
+	if b.state == CircuitClosed {
+		b.mu.Unlock()
+		return false, nil
+	}
+	if b.state == CircuitHalfOpen || time.Since(b.openedAt) < b.opts.OpenTimeout {
+		b.mu.Unlock()
+		return false, ErrCircuitOpen
+	}
+	from := b.setState(CircuitHalfOpen)
+	b.mu.Unlock()
+	b.notify(from, CircuitHalfOpen)
+	return true, nil
+}
+
+// record counts the outcome of a call made while the circuit was closed
+func (b *CircuitBreaker) record(err error) {
+	b.mu.Lock()
+	if b.state != CircuitClosed {
+		b.mu.Unlock()
+		return
+	}
+	if !b.opts.IsFailure(err) {
+		b.failures = 0
+		b.mu.Unlock()
+		return
+	}
+	b.failures++
+	if b.failures < b.opts.FailureThreshold {
+		b.mu.Unlock()
+		return
+	}
+	from := b.setState(CircuitOpen)
+	b.mu.Unlock()
+	b.notify(from, CircuitOpen)
+}
+
+// finishProbe closes the circuit if the probe succeeded and opens it again otherwise
+func (b *CircuitBreaker) finishProbe(ok bool) {
+	to := CircuitOpen
+	if ok {
+		to = CircuitClosed
+	}
+	b.mu.Lock()
+	from := b.setState(to)
+	b.mu.Unlock()
+	b.notify(from, to)
+}
+
+// setState moves to state and returns the previous one. The caller must hold b.mu.
+func (b *CircuitBreaker) setState(state CircuitState) CircuitState {
+	from := b.state
+	b.state = state
+	b.failures = 0
+	if state == CircuitOpen {
+		b.openedAt = time.Now()
+	}
+	return from
+}
+
+// notify calls the state change hook
+func (b *CircuitBreaker) notify(from, to CircuitState) {
+	if from != to && b.opts.OnStateChange != nil {
+		b.opts.OnStateChange(from, to)
+	}
+}
+
+// circuitRequester guards calls with a CircuitBreaker
+type circuitRequester struct {
+	next      Requester
+	breaker   *CircuitBreaker
+	metrics   MetricsRecorder             // Optional
+	probeBody func() (interface{}, error) // Builds the GetBalance body sent as a probe
+}
+
+// DoInto sends the request unless the circuit is open. A GetBalance call made when a probe
+// is due serves as the probe itself.
+func (r *circuitRequester) DoInto(ctx context.Context, endpoint string, body, out interface{}) error {
+	err := r.do(ctx, endpoint, body, out)
+	if r.metrics != nil {
+		if errors.Is(err, ErrCircuitOpen) {
+			r.metrics.IncCircuitRejected(endpoint)
+		}
+		r.metrics.SetCircuitState(r.breaker.State())
+	}
+	return err
+}
+
+// do sends the request through the breaker
+func (r *circuitRequester) do(ctx context.Context, endpoint string, body, out interface{}) error {
+	probe, err := r.breaker.allow()
+	if err != nil {
+		return err
+	}
+	if !probe {
+		err := r.next.DoInto(ctx, endpoint, body, out)
+		r.record(ctx, err)
+		return err
+	}
+
+	if endpoint == GetBalanceEndpoint {
+		err := r.next.DoInto(ctx, endpoint, body, out)
+		r.breaker.finishProbe(r.probeSucceeded(ctx, err))
+		return err
+	}
+	probeBody, err := r.probeBody()
+	if err != nil {
+		r.breaker.finishProbe(false)
+		return err
+	}
+	err = r.next.DoInto(ctx, GetBalanceEndpoint, probeBody, new(BalanceResponse))
+	ok := r.probeSucceeded(ctx, err)
+	r.breaker.finishProbe(ok)
+	if !ok {
+		return ErrCircuitOpen
+	}
+	err = r.next.DoInto(ctx, endpoint, body, out)
+	r.record(ctx, err)
+	return err
+}
+
+// record counts the outcome of a call unless the caller cancelled it or its deadline expired,
+// which says nothing about the API
+func (r *circuitRequester) record(ctx context.Context, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	r.breaker.record(err)
+}
+
+// probeSucceeded reports whether a probe got an answer. A probe cut short by ctx proves nothing
+// and counts as failed so that the circuit stays open.
+func (r *circuitRequester) probeSucceeded(ctx context.Context, err error) bool {
+	return err == nil || (ctx.Err() == nil && !r.breaker.opts.IsFailure(err))
+}
@@ -0,0 +1,152 @@
+package Intouchpay_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+	"github.com/stretchr/testify/assert"
+)
+
+// OutageHTTPClient implements APIRequester and fails every call with Err while Err is set
+type OutageHTTPClient struct {
+	Err       error
+	Endpoints []string
+}
+
+func (o *OutageHTTPClient) Do(endpoint string, _ interface{}) (*map[string]interface{}, error) {
+	o.Endpoints = append(o.Endpoints, endpoint)
+	if o.Err != nil {
+		return nil, o.Err
+	}
+	return &map[string]interface{}{"success": true, "responsecode": "2001", "balance": 100}, nil
+}
+
+// TestCircuitBreakerOpensAndProbes tests opening, failing fast and recovering through a GetBalance probe
+func TestCircuitBreakerOpensAndProbes(t *testing.T) {
+	var changes []string
+	breaker := Intouchpay.NewCircuitBreaker(&Intouchpay.CircuitBreakerOptions{
+		FailureThreshold: 2,
+		OpenTimeout:      20 * time.Millisecond,
+		OnStateChange: func(from, to Intouchpay.CircuitState) {
+			changes = append(changes, from.String()+">"+to.String())
+		},
+	})
+	requester := &OutageHTTPClient{Err: &Intouchpay.APIError{StatusCode: http.StatusServiceUnavailable}}
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, requester, Intouchpay.WithCircuitBreaker(breaker))
+	status := &Intouchpay.GetTransactionStatusParams{RequestTransactionID: "TX1"}
+	deposit := &Intouchpay.RequestDepositParams{Amount: Intouchpay.RWF(100), MobilePhone: "0781234567", RequestTransactionID: "D1"}
+
+	for i := 0; i < 2; i++ {
+		_, err := client.GetTransactionStatus(status)
+		assert.True(t, Intouchpay.IsAPIError(err))
+	}
+	assert.Equal(t, Intouchpay.CircuitOpen, breaker.State())
+
+	_, err := client.GetTransactionStatus(status)
+	assert.ErrorIs(t, err, Intouchpay.ErrCircuitOpen)
+	assert.True(t, Intouchpay.IsRequestNotSent(err))
+	assert.Len(t, requester.Endpoints, 2)
+
+	// The API is still down when the probe is sent
+	time.Sleep(30 * time.Millisecond)
+	_, err = client.RequestDeposit(deposit)
+	assert.ErrorIs(t, err, Intouchpay.ErrCircuitOpen)
+	assert.Equal(t, Intouchpay.CircuitOpen, breaker.State())
+
+	requester.Err = nil
+	time.Sleep(30 * time.Millisecond)
+	_, err = client.RequestDeposit(deposit)
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.CircuitClosed, breaker.State())
+
+	assert.Equal(t, []string{
+		Intouchpay.GetTransactionStatusEndpoint,
+		Intouchpay.GetTransactionStatusEndpoint,
+		Intouchpay.GetBalanceEndpoint,
+		Intouchpay.GetBalanceEndpoint,
+		Intouchpay.RequestDepositEndpoint,
+	}, requester.Endpoints)
+	assert.Equal(t, []string{"closed>open", "open>half_open", "half_open>open", "open>half_open", "half_open>closed"}, changes)
+}
+
+// TestCircuitBreakerIgnoresClientErrors tests that answers from the API never open the circuit
+func TestCircuitBreakerIgnoresClientErrors(t *testing.T) {
+	breaker := Intouchpay.NewCircuitBreaker(&Intouchpay.CircuitBreakerOptions{FailureThreshold: 2})
+	requester := &OutageHTTPClient{Err: &Intouchpay.APIError{StatusCode: http.StatusBadRequest}}
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, requester, Intouchpay.WithCircuitBreaker(breaker))
+
+	for i := 0; i < 5; i++ {
+		_, err := client.GetBalance()
+		assert.False(t, errors.Is(err, Intouchpay.ErrCircuitOpen))
+	}
+
+	assert.Equal(t, Intouchpay.CircuitClosed, breaker.State())
+	assert.Len(t, requester.Endpoints, 5)
+}
+
+// TestCircuitBreakerIgnoresCallerCancellation tests that calls cancelled by the caller, or cut
+// short by the caller's deadline, never open the circuit, while HTTP client timeouts do
+func TestCircuitBreakerIgnoresCallerCancellation(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		_, _ = w.Write([]byte(`{"success":true,"balance":100}`))
+	}))
+	defer server.Close()
+	breaker := Intouchpay.NewCircuitBreaker(&Intouchpay.CircuitBreakerOptions{FailureThreshold: 2, OpenTimeout: time.Hour})
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{},
+		Intouchpay.NewHTTPClient(&http.Client{Timeout: 5 * time.Second}, server.URL),
+		Intouchpay.WithCircuitBreaker(breaker),
+	)
+
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+		}()
+		_, err := client.GetBalanceContext(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+		_, err = client.GetBalanceContext(ctx)
+		cancel()
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	}
+	assert.Equal(t, Intouchpay.CircuitClosed, breaker.State())
+	assert.False(t, Intouchpay.DefaultCircuitFailure(fmt.Errorf("call: %w", context.Canceled)))
+
+	close(release)
+	_, err := client.GetBalance()
+	assert.NoError(t, err)
+
+	// The HTTP client's own timeout is a real failure
+	stop := make(chan struct{})
+	stalled := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		select {
+		case <-stop:
+		case <-r.Context().Done():
+		}
+	}))
+	defer stalled.Close()
+	defer close(stop)
+	client = Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{},
+		Intouchpay.NewHTTPClient(&http.Client{Timeout: 10 * time.Millisecond}, stalled.URL),
+		Intouchpay.WithCircuitBreaker(breaker),
+	)
+	for i := 0; i < 2; i++ {
+		_, err = client.GetBalance()
+		assert.Error(t, err)
+	}
+	assert.Equal(t, Intouchpay.CircuitOpen, breaker.State())
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+)
+
+// Environment variables read by the CLI, besides the credential variables read by
+// Intouchpay.EnvCredentials
+const (
+	envCallbackURL = "INTOUCHPAY_CALLBACK_URL"
+	envSid         = "INTOUCHPAY_SID"
+	envBaseURL     = "INTOUCHPAY_BASE_URL"
+	envEnvironment = "INTOUCHPAY_ENVIRONMENT"
+	envConfig      = "INTOUCHPAY_CONFIG"
+)
+
+// config holds the settings needed to build a Client.
+// Values are resolved from flags, then environment variables, then the config file.
+type config struct {
+	Username        string `json:"username"`
+	AccountNumber   string `json:"account_number"`
+	PartnerPassword string `json:"partner_password"`
+	CallbackURL     string `json:"callback_url,omitempty"`
+	Sid             *int   `json:"sid,omitempty"`
+	BaseURL         string `json:"base_url,omitempty"`
+	Environment     string `json:"environment,omitempty"`
+}
+
+// commonFlags are the flags shared by every subcommand
+type commonFlags struct {
+	configPath string
+	cfg        config
+	sid        int
+	jsonOutput bool
+	timeout    time.Duration
+}
+
+// register adds the common flags to fs
+func (f *commonFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.configPath, "config", "", "path to a JSON config file (default $"+envConfig+" or ~/.config/intouchpay/config.json)")
+	fs.StringVar(&f.cfg.Username, "username", "", "IntouchPay username ($"+Intouchpay.EnvUsername+")")
+	fs.StringVar(&f.cfg.AccountNumber, "account", "", "IntouchPay account number ($"+Intouchpay.EnvAccountNumber+")")
+	fs.StringVar(&f.cfg.PartnerPassword, "password", "", "IntouchPay partner password ($"+Intouchpay.EnvPartnerPassword+")")
+	fs.StringVar(&f.cfg.CallbackURL, "callback-url", "", "callback URL for payments ($"+envCallbackURL+")")
+	fs.IntVar(&f.sid, "sid", -1, "service ID, 0 or 1 ($"+envSid+")")
+	fs.StringVar(&f.cfg.BaseURL, "base-url", "", "API base URL ($"+envBaseURL+")")
+	fs.StringVar(&f.cfg.Environment, "env", "", "environment the account belongs to: production, sandbox or custom ($"+envEnvironment+")")
+	fs.BoolVar(&f.jsonOutput, "json", false, "print JSON instead of text")
+	fs.DurationVar(&f.timeout, "timeout", Intouchpay.DefaultTimeout, "request timeout")
+}
+
+// resolve merges flags, environment and config file into a complete config
+func (f *commonFlags) resolve(getenv func(string) string) (config, error) {
+	cfg := f.cfg
+	if f.sid >= 0 {
+		sid := f.sid
+		cfg.Sid = &sid
+	}
+
+	fromEnv := config{
+		Username:        getenv(Intouchpay.EnvUsername),
+		AccountNumber:   getenv(Intouchpay.EnvAccountNumber),
+		PartnerPassword: getenv(Intouchpay.EnvPartnerPassword),
+		CallbackURL:     getenv(envCallbackURL),
+		BaseURL:         getenv(envBaseURL),
+		Environment:     getenv(envEnvironment),
+	}
+	if value := getenv(envSid); value != "" {
+		sid, err := strconv.Atoi(value)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid %s: %w", envSid, err)
+		}
+		fromEnv.Sid = &sid
+	}
+	cfg.merge(fromEnv)
+
+	fromFile, err := loadConfigFile(f.configPath, getenv)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.merge(fromFile)
+
+	if cfg.Username == "" || cfg.AccountNumber == "" || cfg.PartnerPassword == "" {
+		return cfg, errors.New("username, account number and partner password are required")
+	}
+	switch Intouchpay.Environment(cfg.Environment) {
+	case "", Intouchpay.EnvironmentProduction, Intouchpay.EnvironmentSandbox, Intouchpay.EnvironmentCustom:
+	default:
+		return cfg, fmt.Errorf("invalid environment %q: want production, sandbox or custom", cfg.Environment)
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = Intouchpay.BaseURL
+	}
+	return cfg, nil
+}
+
+// merge fills the empty fields of c from other
+func (c *config) merge(other config) {
+	if c.Username == "" {
+		c.Username = other.Username
+	}
+	if c.AccountNumber == "" {
+		c.AccountNumber = other.AccountNumber
+	}
+	if c.PartnerPassword == "" {
+		c.PartnerPassword = other.PartnerPassword
+	}
+	if c.CallbackURL == "" {
+		c.CallbackURL = other.CallbackURL
+	}
+	if c.Sid == nil {
+		c.Sid = other.Sid
+	}
+	if c.BaseURL == "" {
+		c.BaseURL = other.BaseURL
+	}
+	if c.Environment == "" {
+		c.Environment = other.Environment
+	}
+}
+
+// loadConfigFile reads the config file at path, or at the default location if path is empty.
+// A missing default file is not an error.
+func loadConfigFile(path string, getenv func(string) string) (config, error) {
+	var cfg config
+	explicit := path != ""
+	if !explicit {
+		path = getenv(envConfig)
+		explicit = path != ""
+	}
+	if !explicit {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return cfg, nil
+		}
+		path = filepath.Join(dir, "intouchpay", "config.json")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// newClient builds a Client from cfg
+func (f *commonFlags) newClient(cfg config) *Intouchpay.Client {
+	opts := []Intouchpay.Option{
+		Intouchpay.WithCallbackURL(cfg.CallbackURL),
+		Intouchpay.WithTimeout(f.timeout),
+		Intouchpay.WithBaseURL(cfg.BaseURL),
+	}
+	if cfg.Sid != nil {
+		opts = append(opts, Intouchpay.WithSid(*cfg.Sid))
+	}
+	if cfg.Environment != "" {
+		opts = append(opts, Intouchpay.WithEnvironment(Intouchpay.Environment(cfg.Environment)))
+	}
+	return Intouchpay.NewClientWithOptions(cfg.Username, cfg.AccountNumber, cfg.PartnerPassword, opts...)
+}
@@ -0,0 +1,280 @@
+// Command intouchpay is a command-line client for the IntouchPay API.
+//
+// Usage:
+//
+//	intouchpay balance  [flags]
+//	intouchpay pay      [flags] -amount 1000 -phone 0788123456 -id TX1
+//	intouchpay deposit  [flags] -amount 1000 -phone 0788123456 -id TX2 -reason "Refund"
+//	intouchpay status   [flags] -id TX1 [-transaction-id 1425] [-wait 2m]
+//
+// Credentials are read from flags, then INTOUCHPAY_* environment variables, then a JSON
+// config file. The exit status reflects the class of the response code; see the exit* constants.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+)
+
+// Exit statuses
+const (
+	exitSuccess          = 0
+	exitError            = 1 // Configuration, network or HTTP errors
+	exitUsage            = 2
+	exitPending          = 10
+	exitPermanentFailure = 11
+	exitRetryableFailure = 12
+	exitAuthFailure      = 13
+	exitUnknownCode      = 14
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr, os.Getenv))
+}
+
+// run executes the CLI and returns its exit status
+func run(args []string, stdout, stderr io.Writer, getenv func(string) string) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitUsage
+	}
+
+	commands := map[string]func([]string, io.Writer, io.Writer, func(string) string) int{
+		"balance": runBalance,
+		"pay":     runPay,
+		"deposit": runDeposit,
+		"status":  runStatus,
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		if args[0] != "help" && args[0] != "-h" && args[0] != "--help" {
+			fmt.Fprintf(stderr, "unknown command %q\n", args[0])
+		}
+		usage(stderr)
+		return exitUsage
+	}
+	return command(args[1:], stdout, stderr, getenv)
+}
+
+// usage prints the list of subcommands
+func usage(w io.Writer) {
+	fmt.Fprint(w, `Usage: intouchpay <command> [flags]
+
+Commands:
+  balance   show the account balance
+  pay       request a payment from a subscriber
+  deposit   send a deposit to a subscriber
+  status    show the status of a transaction
+
+Run "intouchpay <command> -h" for the flags of a command.
+`)
+}
+
+// runBalance implements the balance command
+func runBalance(args []string, stdout, stderr io.Writer, getenv func(string) string) int {
+	var common commonFlags
+	fs := newFlagSet("balance", stderr)
+	common.register(fs)
+	client, code := setup(fs, &common, args, stderr, getenv)
+	if client == nil {
+		return code
+	}
+
+	resp, err := client.GetBalanceContext(context.Background())
+	if err != nil {
+		return fail(stderr, err)
+	}
+	code = exitSuccess
+	if !resp.Success {
+		code = exitStatus(resp.Code())
+	}
+	return output(stdout, common.jsonOutput, resp, code, func(w io.Writer) {
+		fmt.Fprintf(w, "Balance: %v\n", resp.Balance)
+		printCode(w, resp.Code(), resp.Message)
+	})
+}
+
+// runPay implements the pay command
+func runPay(args []string, stdout, stderr io.Writer, getenv func(string) string) int {
+	var common commonFlags
+	var params Intouchpay.RequestPaymentParams
+	fs := newFlagSet("pay", stderr)
+	common.register(fs)
+	fs.Func("amount", "amount in RWF, such as 1500 or \"1,500 RWF\"", func(value string) (err error) {
+		params.Amount, err = Intouchpay.ParseMoney(value)
+		return err
+	})
+	fs.StringVar(&params.MobilePhone, "phone", "", "subscriber phone number")
+	fs.StringVar(&params.RequestTransactionID, "id", "", "unique request transaction ID")
+	client, code := setup(fs, &common, args, stderr, getenv)
+	if client == nil {
+		return code
+	}
+	if params.Amount.IsZero() || params.MobilePhone == "" || params.RequestTransactionID == "" {
+		fmt.Fprintln(stderr, "pay requires -amount, -phone and -id")
+		return exitUsage
+	}
+	resp, err := client.RequestPaymentContext(context.Background(), &params)
+	if err != nil {
+		return fail(stderr, err)
+	}
+	return output(stdout, common.jsonOutput, resp, exitStatus(resp.Code()), func(w io.Writer) {
+		fmt.Fprintf(w, "Request transaction ID: %s\n", resp.RequestTransactionID)
+		fmt.Fprintf(w, "Transaction ID: %s\n", resp.TransactionID)
+		fmt.Fprintf(w, "Status: %s\n", resp.Status)
+		printCode(w, resp.Code(), resp.Message)
+	})
+}
+
+// runDeposit implements the deposit command
+func runDeposit(args []string, stdout, stderr io.Writer, getenv func(string) string) int {
+	var common commonFlags
+	var params Intouchpay.RequestDepositParams
+	fs := newFlagSet("deposit", stderr)
+	common.register(fs)
+	fs.Func("amount", "amount in RWF, such as 1500 or \"1,500 RWF\"", func(value string) (err error) {
+		params.Amount, err = Intouchpay.ParseMoney(value)
+		return err
+	})
+	fs.StringVar(&params.MobilePhone, "phone", "", "subscriber phone number")
+	fs.StringVar(&params.RequestTransactionID, "id", "", "unique request transaction ID")
+	fs.StringVar(&params.Reason, "reason", "", "reason for the deposit")
+	fs.IntVar(&params.WithdrawCharge, "withdraw-charge", 0, "set to 1 to include withdraw charges in the amount")
+	client, code := setup(fs, &common, args, stderr, getenv)
+	if client == nil {
+		return code
+	}
+	if params.Amount.IsZero() || params.MobilePhone == "" || params.RequestTransactionID == "" {
+		fmt.Fprintln(stderr, "deposit requires -amount, -phone and -id")
+		return exitUsage
+	}
+	resp, err := client.RequestDepositContext(context.Background(), &params)
+	if err != nil {
+		return fail(stderr, err)
+	}
+	return output(stdout, common.jsonOutput, resp, exitStatus(resp.Code()), func(w io.Writer) {
+		fmt.Fprintf(w, "Request transaction ID: %s\n", resp.RequestTransactionID)
+		fmt.Fprintf(w, "Reference ID: %s\n", resp.ReferenceID)
+		printCode(w, resp.Code(), "")
+	})
+}
+
+// runStatus implements the status command
+func runStatus(args []string, stdout, stderr io.Writer, getenv func(string) string) int {
+	var common commonFlags
+	var params Intouchpay.GetTransactionStatusParams
+	var wait time.Duration
+	fs := newFlagSet("status", stderr)
+	common.register(fs)
+	fs.StringVar(&params.RequestTransactionID, "id", "", "request transaction ID")
+	fs.StringVar(&params.TransactionID, "transaction-id", "", "IntouchPay transaction ID")
+	fs.DurationVar(&wait, "wait", 0, "poll until the transaction is final or this much time has passed")
+	client, code := setup(fs, &common, args, stderr, getenv)
+	if client == nil {
+		return code
+	}
+	if params.RequestTransactionID == "" {
+		fmt.Fprintln(stderr, "status requires -id")
+		return exitUsage
+	}
+
+	var resp *Intouchpay.GetTransactionStatusResponse
+	var err error
+	if wait > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), wait)
+		defer cancel()
+		resp, _, err = client.WaitForFinalStatus(ctx, &params, nil)
+		if errors.Is(err, context.DeadlineExceeded) && resp != nil {
+			err = nil
+		}
+	} else {
+		resp, err = client.GetTransactionStatusContext(context.Background(), &params)
+	}
+	if err != nil {
+		return fail(stderr, err)
+	}
+	return output(stdout, common.jsonOutput, resp, exitStatus(resp.Code()), func(w io.Writer) {
+		fmt.Fprintf(w, "Status: %s\n", resp.Status)
+		printCode(w, resp.Code(), resp.Message)
+	})
+}
+
+// newFlagSet creates a FlagSet for a subcommand that reports errors instead of exiting
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("intouchpay "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+// setup parses args and builds the client. On failure it returns a nil client and the exit status.
+func setup(fs *flag.FlagSet, common *commonFlags, args []string, stderr io.Writer, getenv func(string) string) (*Intouchpay.Client, int) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, exitSuccess
+		}
+		return nil, exitUsage
+	}
+	cfg, err := common.resolve(getenv)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return nil, exitError
+	}
+	return common.newClient(cfg), exitSuccess
+}
+
+// exitStatus maps a response code to the exit status of its class
+func exitStatus(code Intouchpay.ResponseCode) int {
+	switch code.Class() {
+	case Intouchpay.ClassSuccess:
+		return exitSuccess
+	case Intouchpay.ClassPending:
+		return exitPending
+	case Intouchpay.ClassPermanentFailure:
+		return exitPermanentFailure
+	case Intouchpay.ClassRetryableFailure:
+		return exitRetryableFailure
+	case Intouchpay.ClassAuthFailure:
+		return exitAuthFailure
+	default:
+		return exitUnknownCode
+	}
+}
+
+// fail reports a request error
+func fail(stderr io.Writer, err error) int {
+	fmt.Fprintf(stderr, "error: %v\n", err)
+	return exitError
+}
+
+// output prints resp as JSON or as text and returns code
+func output(stdout io.Writer, jsonOutput bool, resp interface{}, code int, text func(io.Writer)) int {
+	if !jsonOutput {
+		text(stdout)
+		return code
+	}
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(resp); err != nil {
+		return exitError
+	}
+	return code
+}
+
+// printCode prints a response code with its description and class
+func printCode(w io.Writer, code Intouchpay.ResponseCode, message string) {
+	if code == "" {
+		return
+	}
+	fmt.Fprintf(w, "Response code: %s (%s, %s)\n", code, code.Description(), code.Class())
+	if message != "" {
+		fmt.Fprintf(w, "Message: %s\n", message)
+	}
+}
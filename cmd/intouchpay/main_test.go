@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+	"github.com/samueltuyizere/go-intouchpay/intouchpaytest"
+	"github.com/stretchr/testify/assert"
+)
+
+// envFor returns a getenv function exposing the fake server's credentials and an empty config file
+func envFor(t *testing.T, server *intouchpaytest.Server) func(string) string {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	assert.NoError(t, os.WriteFile(configPath, []byte("{}"), 0o600))
+	env := map[string]string{
+		Intouchpay.EnvUsername:        server.Username,
+		Intouchpay.EnvAccountNumber:   server.AccountNo,
+		Intouchpay.EnvPartnerPassword: server.PartnerPassword,
+		envBaseURL:                    server.URL,
+		envConfig:                     configPath,
+	}
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+// TestRunBalanceJSON tests the balance command with JSON output
+func TestRunBalanceJSON(t *testing.T) {
+	server := intouchpaytest.NewServer(intouchpaytest.WithBalance(Intouchpay.RWF(2500)))
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"balance", "-json"}, &stdout, &stderr, envFor(t, server))
+
+	assert.Equal(t, exitSuccess, code, stderr.String())
+	var resp Intouchpay.BalanceResponse
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &resp))
+	assert.Equal(t, Intouchpay.RWF(2500), resp.Balance)
+}
+
+// TestRunExitCodesFollowResponseClass tests that exit statuses match response code classes
+func TestRunExitCodesFollowResponseClass(t *testing.T) {
+	server := intouchpaytest.NewServer(intouchpaytest.WithBalance(Intouchpay.RWF(100)))
+	defer server.Close()
+	env := envFor(t, server)
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"pay", "-amount", "500", "-phone", "0781234567", "-id", "TX1"}, &stdout, &stderr, env)
+	assert.Equal(t, exitPending, code, stderr.String())
+	assert.Contains(t, stdout.String(), "1000")
+
+	code = run([]string{"deposit", "-amount", "500", "-phone", "0781234567", "-id", "D1"}, &stdout, &stderr, env)
+	assert.Equal(t, exitRetryableFailure, code)
+
+	code = run([]string{"status", "-id", "missing"}, &stdout, &stderr, env)
+	assert.Equal(t, exitPermanentFailure, code)
+
+	code = run([]string{"balance", "-password", "wrong"}, &stdout, &stderr, env)
+	assert.Equal(t, exitAuthFailure, code)
+}
+
+// TestRunUsageErrors tests argument validation
+func TestRunUsageErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	noEnv := func(string) string { return "" }
+
+	assert.Equal(t, exitUsage, run(nil, &stdout, &stderr, noEnv))
+	assert.Equal(t, exitUsage, run([]string{"refund"}, &stdout, &stderr, noEnv))
+	assert.Equal(t, exitError, run([]string{"balance", "-config", filepath.Join(t.TempDir(), "none.json")}, &stdout, &stderr, noEnv))
+}
+
+// TestResolvePrecedence tests that flags override environment variables which override the config file
+func TestResolvePrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"username":"file-user","account_number":"file-acc","partner_password":"file-pass","sid":1}`), 0o600))
+
+	common := commonFlags{configPath: path, sid: -1}
+	common.cfg.Username = "flag-user"
+	cfg, err := common.resolve(func(key string) string {
+		if key == Intouchpay.EnvAccountNumber {
+			return "env-acc"
+		}
+		return ""
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "flag-user", cfg.Username)
+	assert.Equal(t, "env-acc", cfg.AccountNumber)
+	assert.Equal(t, "file-pass", cfg.PartnerPassword)
+	assert.Equal(t, 1, *cfg.Sid)
+	assert.Equal(t, Intouchpay.BaseURL, cfg.BaseURL)
+}
+
+// TestResolveEnvironment tests that the environment is read and checked
+func TestResolveEnvironment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"username":"u","account_number":"a","partner_password":"p","environment":"sandbox"}`), 0o600))
+
+	common := commonFlags{configPath: path, sid: -1}
+	cfg, err := common.resolve(func(string) string { return "" })
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.EnvironmentSandbox, common.newClient(cfg).Environment())
+
+	common.cfg.Environment = "staging"
+	_, err = common.resolve(func(string) string { return "" })
+	assert.EqualError(t, err, `invalid environment "staging": want production, sandbox or custom`)
+}
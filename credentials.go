@@ -0,0 +1,135 @@
+package Intouchpay
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Environment variables read by EnvCredentials
+const (
+	EnvUsername        = "INTOUCHPAY_USERNAME"
+	EnvAccountNumber   = "INTOUCHPAY_ACCOUNT_NUMBER"
+	EnvPartnerPassword = "INTOUCHPAY_PARTNER_PASSWORD"
+)
+
+// AccountCredentials are the long-lived secrets of an IntouchPay account
+type AccountCredentials struct {
+	Username        string `json:"username"`
+	AccountNo       string `json:"account_number"`
+	PartnerPassword string `json:"partner_password"`
+}
+
+// validate reports missing fields
+func (c AccountCredentials) validate() error {
+	switch {
+	case c.Username == "":
+		return newValidationError("username", "username is empty")
+	case c.AccountNo == "":
+		return newValidationError("accountno", "account number is empty")
+	case c.PartnerPassword == "":
+		return newValidationError("partnerpassword", "partner password is empty")
+	}
+	return nil
+}
+
+// String hides the partner password so credentials can be printed safely
+func (c AccountCredentials) String() string {
+	return fmt.Sprintf("{Username:%s AccountNo:%s PartnerPassword:%s}", c.Username, c.AccountNo, Redacted)
+}
+
+// CredentialProvider supplies account credentials. The default authenticator calls it for
+// every request, so rotated secrets take effect without rebuilding the client.
+type CredentialProvider interface {
+	Credentials() (AccountCredentials, error)
+}
+
+// CredentialFunc adapts a function to a CredentialProvider
+type CredentialFunc func() (AccountCredentials, error)
+
+// Credentials calls f
+func (f CredentialFunc) Credentials() (AccountCredentials, error) {
+	return f()
+}
+
+// StaticCredentials returns a provider that always supplies the same credentials
+func StaticCredentials(username, accountNo, partnerPassword string) CredentialProvider {
+	creds := AccountCredentials{Username: username, AccountNo: accountNo, PartnerPassword: partnerPassword}
+	return CredentialFunc(func() (AccountCredentials, error) {
+		return creds, nil
+	})
+}
+
+// EnvCredentials returns a provider that reads the INTOUCHPAY_USERNAME,
+// INTOUCHPAY_ACCOUNT_NUMBER and INTOUCHPAY_PARTNER_PASSWORD environment variables on every call
+func EnvCredentials() CredentialProvider {
+	return CredentialFunc(func() (AccountCredentials, error) {
+		creds := AccountCredentials{
+			Username:        os.Getenv(EnvUsername),
+			AccountNo:       os.Getenv(EnvAccountNumber),
+			PartnerPassword: os.Getenv(EnvPartnerPassword),
+		}
+		return creds, creds.validate()
+	})
+}
+
+// FileCredentials returns a provider that reads a JSON file with username, account_number and
+// partner_password fields, the format of the intouchpay command's config file. The file is
+// read again whenever its modification time or size changes.
+func FileCredentials(path string) CredentialProvider {
+	return &fileCredentials{path: path}
+}
+
+// fileCredentials caches the contents of a credentials file
+type fileCredentials struct {
+	path    string
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	creds   AccountCredentials
+}
+
+// Credentials returns the credentials in the file, reloading it if it changed
+func (f *fileCredentials) Credentials() (AccountCredentials, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return AccountCredentials{}, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.creds, nil
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return AccountCredentials{}, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	var creds AccountCredentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return AccountCredentials{}, NewMarshalError("credentials file", err)
+	}
+	if err := creds.validate(); err != nil {
+		return AccountCredentials{}, err
+	}
+	f.creds, f.modTime, f.size = creds, info.ModTime(), info.Size()
+	return creds, nil
+}
+
+// NewClientWithCredentials creates a client whose credentials come from provider. The provider
+// is read once to check it and to fill in Username and AccountNo, then again for every request.
+func NewClientWithCredentials(provider CredentialProvider, opts ...Option) (*Client, error) {
+	creds, err := provider.Credentials()
+	if err != nil {
+		return nil, err
+	}
+	if err := creds.validate(); err != nil {
+		return nil, err
+	}
+	c := NewClientWithAuth(NewAuthenticatorWithProvider(provider), opts...)
+	c.Username = creds.Username
+	c.AccountNo = creds.AccountNo
+	return c, nil
+}
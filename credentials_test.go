@@ -0,0 +1,183 @@
+package Intouchpay_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+	"github.com/samueltuyizere/go-intouchpay/intouchpaytest"
+	"github.com/stretchr/testify/assert"
+)
+
+// expectedPassword hashes credentials the way the API expects
+func expectedPassword(creds Intouchpay.AccountCredentials, timestamp string) string {
+	hash := sha256.Sum256([]byte(creds.Username + creds.AccountNo + creds.PartnerPassword + timestamp))
+	return hex.EncodeToString(hash[:])
+}
+
+// TestAuthenticatorRotatesCredentials tests that a rotated password is used by the next call
+// and that the last good credentials are kept while the provider fails
+func TestAuthenticatorRotatesCredentials(t *testing.T) {
+	var mu sync.Mutex
+	current := Intouchpay.AccountCredentials{Username: "testuser", AccountNo: "1234567890", PartnerPassword: "old"}
+	var failure error
+	auth := Intouchpay.NewAuthenticatorWithProvider(Intouchpay.CredentialFunc(func() (Intouchpay.AccountCredentials, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return current, failure
+	}))
+
+	creds := auth.Authenticate()
+	assert.Equal(t, expectedPassword(current, creds.Timestamp), creds.Password)
+
+	mu.Lock()
+	rotated := current
+	rotated.PartnerPassword = "new"
+	current = rotated
+	mu.Unlock()
+	creds = auth.Authenticate()
+	assert.Equal(t, expectedPassword(rotated, creds.Timestamp), creds.Password)
+
+	mu.Lock()
+	current, failure = Intouchpay.AccountCredentials{}, errors.New("vault unavailable")
+	mu.Unlock()
+	creds = auth.Authenticate()
+	assert.Equal(t, "testuser", creds.Username)
+	assert.Equal(t, expectedPassword(rotated, creds.Timestamp), creds.Password)
+}
+
+// TestFileCredentialsReload tests that the file is read again after it changes
+func TestFileCredentialsReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"username":"testuser","account_number":"1234567890","partner_password":"old"}`), 0o600))
+	provider := Intouchpay.FileCredentials(path)
+
+	creds, err := provider.Credentials()
+	assert.NoError(t, err)
+	assert.Equal(t, "old", creds.PartnerPassword)
+
+	assert.NoError(t, os.WriteFile(path, []byte(`{"username":"testuser","account_number":"1234567890","partner_password":"rotated"}`), 0o600))
+	assert.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	creds, err = provider.Credentials()
+	assert.NoError(t, err)
+	assert.Equal(t, "rotated", creds.PartnerPassword)
+
+	assert.NoError(t, os.WriteFile(path, []byte(`{"username":"testuser"}`), 0o600))
+	_, err = provider.Credentials()
+	var validationErr *Intouchpay.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+
+	_, err = Intouchpay.FileCredentials(filepath.Join(t.TempDir(), "missing.json")).Credentials()
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+// TestEnvCredentials tests reading credentials from the environment
+func TestEnvCredentials(t *testing.T) {
+	t.Setenv(Intouchpay.EnvUsername, "testuser")
+	t.Setenv(Intouchpay.EnvAccountNumber, "1234567890")
+	t.Setenv(Intouchpay.EnvPartnerPassword, "")
+
+	_, err := Intouchpay.EnvCredentials().Credentials()
+	assert.Error(t, err)
+
+	t.Setenv(Intouchpay.EnvPartnerPassword, "secret")
+	creds, err := Intouchpay.EnvCredentials().Credentials()
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", creds.PartnerPassword)
+	assert.NotContains(t, creds.String(), "secret")
+}
+
+// TestNewClientWithCredentials tests that a client picks up a rotated password without being rebuilt
+func TestNewClientWithCredentials(t *testing.T) {
+	server := intouchpaytest.NewServer(intouchpaytest.WithCredentials("testuser", "1234567890", "new"), intouchpaytest.WithBalance(Intouchpay.RWF(500)))
+	defer server.Close()
+
+	var mu sync.Mutex
+	password := "old"
+	client, err := Intouchpay.NewClientWithCredentials(Intouchpay.CredentialFunc(func() (Intouchpay.AccountCredentials, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return Intouchpay.AccountCredentials{Username: "testuser", AccountNo: "1234567890", PartnerPassword: password}, nil
+	}), Intouchpay.WithHTTPClientInterface(Intouchpay.NewHTTPClient(server.Client(), server.URL)))
+	assert.NoError(t, err)
+	assert.Equal(t, "testuser", client.Username)
+	assert.Equal(t, "1234567890", client.AccountNo)
+	assert.Empty(t, client.PartnerPassword)
+
+	balance, err := client.GetBalanceContext(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, balance.Success)
+
+	mu.Lock()
+	password = "new"
+	mu.Unlock()
+	balance, err = client.GetBalanceContext(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, balance.Success)
+	assert.Equal(t, Intouchpay.RWF(500), balance.Balance)
+
+	_, err = Intouchpay.NewClientWithCredentials(Intouchpay.StaticCredentials("testuser", "", "secret"))
+	assert.Error(t, err)
+}
+
+// TestProviderFailureRefusesRequests tests that no request is sent with blank credentials when
+// the provider has never loaded
+func TestProviderFailureRefusesRequests(t *testing.T) {
+	auth := Intouchpay.NewAuthenticatorWithProvider(Intouchpay.CredentialFunc(func() (Intouchpay.AccountCredentials, error) {
+		return Intouchpay.AccountCredentials{}, errors.New("vault unavailable")
+	}))
+	mock := &MockHTTPClient{Response: &map[string]interface{}{"success": true}}
+	client := Intouchpay.NewClientWithHTTPClient(auth, mock)
+
+	_, err := client.GetBalance()
+	assert.ErrorIs(t, err, Intouchpay.ErrNoCredentials)
+	assert.ErrorContains(t, err, "vault unavailable")
+	_, err = client.RequestPayment(&Intouchpay.RequestPaymentParams{Amount: Intouchpay.RWF(100), MobilePhone: "0781234567", RequestTransactionID: "P1"})
+	assert.ErrorIs(t, err, Intouchpay.ErrNoCredentials)
+	var out map[string]interface{}
+	assert.ErrorIs(t, client.Call(context.Background(), Intouchpay.GetBalanceEndpoint, nil, &out), Intouchpay.ErrNoCredentials)
+	assert.False(t, mock.Called)
+}
+
+// TestAccountNumberRotation tests that the account number sent is the one the password was
+// hashed with, not the one the client was built with
+func TestAccountNumberRotation(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		_, err := w.Write([]byte(`{"success":true,"balance":10}`))
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	current := Intouchpay.AccountCredentials{Username: "shop", AccountNo: "1111111111", PartnerPassword: "secret"}
+	client, err := Intouchpay.NewClientWithCredentials(Intouchpay.CredentialFunc(func() (Intouchpay.AccountCredentials, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return current, nil
+	}), Intouchpay.WithHTTPClientInterface(Intouchpay.NewHTTPClient(server.Client(), server.URL)))
+	assert.NoError(t, err)
+
+	mu.Lock()
+	current.AccountNo = "2222222222"
+	rotated := current
+	mu.Unlock()
+
+	_, err = client.GetBalance()
+	assert.NoError(t, err)
+	assert.Equal(t, "2222222222", received["accountno"])
+	timestamp, ok := received["timestamp"].(string)
+	assert.True(t, ok)
+	assert.Equal(t, expectedPassword(rotated, timestamp), received["password"])
+}
@@ -0,0 +1,151 @@
+package Intouchpay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Environment names the IntouchPay deployment a client talks to
+type Environment string
+
+// Environments a client can be marked with
+const (
+	// EnvironmentProduction is the live API at BaseURL. Marking a client with it explicitly
+	// turns off the test credentials check of the production guard.
+	EnvironmentProduction Environment = "production"
+	// EnvironmentSandbox is a test deployment. Its URL must be set with WithBaseURL, and a
+	// sandbox client refuses to send to production.
+	EnvironmentSandbox Environment = "sandbox"
+	// EnvironmentCustom is any other deployment, such as a local stand-in
+	EnvironmentCustom Environment = "custom"
+)
+
+// ErrProductionGuard is returned, wrapped, when a client marked as test or using test
+// credentials would send a request to production
+var ErrProductionGuard = errors.New("refusing to send a test request to production")
+
+// testMarkers are the user name words taken as a sign of test credentials
+var testMarkers = []string{"test", "sandbox", "demo", "dummy"}
+
+// Environment returns the environment the client is marked with. A client that was not
+// marked reports EnvironmentProduction when it sends to BaseURL and EnvironmentCustom
+// otherwise.
+func (c *Client) Environment() Environment {
+	if c.environment != "" {
+		return c.environment
+	}
+	if c.httpClient == nil && isProductionURL(c.targetURL()) {
+		return EnvironmentProduction
+	}
+	return EnvironmentCustom
+}
+
+// targetURL returns the base URL of the default requester
+func (c *Client) targetURL() string {
+	if c.baseURL != "" {
+		return c.baseURL
+	}
+	return BaseURL
+}
+
+// transport returns the requester at the bottom of the pipeline: the APIRequester set with
+// WithHTTPClientInterface, or one built from HTTPClient and the base URL
+func (c *Client) transport() Requester {
+	if c.httpClient != nil {
+		return AsRequester(c.httpClient)
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	return AsRequester(NewHTTPClient(httpClient, c.targetURL()))
+}
+
+// productionGuard caches the verdict of the production guard, worked out on the first call
+type productionGuard struct {
+	mu      sync.Mutex
+	checked bool
+	err     error
+}
+
+// checkProduction returns an error wrapping ErrProductionGuard when the client would send a
+// test request to production. The verdict is worked out on the first call that can load
+// credentials and kept for the life of the client. Requesters set with
+// WithHTTPClientInterface are not checked since their URL is unknown.
+func (c *Client) checkProduction() error {
+	if c.httpClient != nil || !isProductionURL(c.targetURL()) || c.environment == EnvironmentProduction {
+		return nil
+	}
+	if c.environment == EnvironmentSandbox {
+		return fmt.Errorf("%w: the client is marked %s", ErrProductionGuard, EnvironmentSandbox)
+	}
+
+	c.guard.mu.Lock()
+	defer c.guard.mu.Unlock()
+	if c.guard.checked {
+		return c.guard.err
+	}
+	username, accountNo := c.Username, c.AccountNo
+	if username == "" || accountNo == "" {
+		creds, err := c.credentials()
+		if err != nil {
+			// The request fails on its own; check again on the next call
+			return nil
+		}
+		username, accountNo = creds.Username, creds.AccountNo
+	}
+	if looksLikeTestCredentials(username, accountNo) {
+		c.guard.err = fmt.Errorf("%w: the credentials of %q look like test credentials; mark the client with WithEnvironment(EnvironmentProduction) if they are not", ErrProductionGuard, username)
+	}
+	c.guard.checked = true
+	return c.guard.err
+}
+
+// placeholderAccountLength is the length of IntouchPay account numbers, the only length at
+// which an account number is taken for a placeholder
+const placeholderAccountLength = 10
+
+// looksLikeTestCredentials reports whether the user name has a test marker as a whole word,
+// such as test_user, shop-demo or Sandbox.Acme, or the account number is a full-length
+// placeholder such as 1234567890 or 0000000000. Words inside other words, as in contest_ltd
+// or latestshop, are not markers.
+func looksLikeTestCredentials(username, accountNo string) bool {
+	words := strings.FieldsFunc(strings.ToLower(username), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, word := range words {
+		if containsString(testMarkers, word) {
+			return true
+		}
+	}
+	if len(accountNo) != placeholderAccountLength || !isDigits(accountNo) {
+		return false
+	}
+	return strings.Count(accountNo, accountNo[:1]) == len(accountNo) ||
+		accountNo == "1234567890" || accountNo == "0123456789"
+}
+
+// isProductionURL reports whether raw points at the production IntouchPay host
+func isProductionURL(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.") == "intouchpay.co.rw"
+}
+
+// refusedRequester fails every call with err without sending anything
+type refusedRequester struct {
+	err error
+}
+
+// DoInto returns the error
+func (r refusedRequester) DoInto(_ context.Context, _ string, _, _ interface{}) error {
+	return r.err
+}
@@ -0,0 +1,127 @@
+package Intouchpay_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+	"github.com/samueltuyizere/go-intouchpay/intouchpaytest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWithBaseURL tests that the default requester follows the base URL and HTTP client options
+func TestWithBaseURL(t *testing.T) {
+	server := intouchpaytest.NewServer(intouchpaytest.WithBalance(Intouchpay.RWF(700)))
+	defer server.Close()
+
+	client := Intouchpay.NewClientWithOptions(server.Username, server.AccountNo, server.PartnerPassword,
+		Intouchpay.WithBaseURL(server.URL+"/"),
+		Intouchpay.WithHTTPClient(server.Client()),
+	)
+	assert.Equal(t, Intouchpay.EnvironmentCustom, client.Environment())
+	resp, err := client.GetBalance()
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.RWF(700), resp.Balance)
+
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	client = Intouchpay.NewClientWithOptions("shop", "0788123456", "secret",
+		Intouchpay.WithBaseURL(slow.URL),
+		Intouchpay.WithTimeout(50*time.Millisecond),
+	)
+	_, err = client.GetBalance()
+	assert.Error(t, err, "WithTimeout must apply to the default requester")
+}
+
+// TestProductionGuard tests that test clients and test credentials never reach production
+func TestProductionGuard(t *testing.T) {
+	stub := &http.Client{Transport: stubTransport{body: `{"success":true,"balance":10}`}}
+	balance := func(username, accountNo string, opts ...Intouchpay.Option) error {
+		opts = append([]Intouchpay.Option{Intouchpay.WithHTTPClient(stub)}, opts...)
+		_, err := Intouchpay.NewClientWithOptions(username, accountNo, "pw", opts...).GetBalance()
+		return err
+	}
+
+	for _, username := range []string{"test_user", "shop-test", "TEST", "demo", "acme.sandbox", "dummy 2", "test1"} {
+		assert.ErrorIs(t, balance(username, "0788123456"), Intouchpay.ErrProductionGuard, username)
+	}
+	for _, accountNo := range []string{"1234567890", "0123456789", "0000000000", "9999999999"} {
+		assert.ErrorIs(t, balance("shop", accountNo), Intouchpay.ErrProductionGuard, accountNo)
+	}
+
+	// Markers inside other words and short digit runs are not test credentials
+	for _, username := range []string{"contest_ltd", "latestshop", "protest", "testament", "democracy_shop", "testuser"} {
+		assert.NoError(t, balance(username, "0788123456"), username)
+	}
+	for _, accountNo := range []string{"01", "012", "11", "22", "123456789", "12345678901", "0788123456"} {
+		assert.NoError(t, balance("shop", accountNo), accountNo)
+	}
+
+	client := Intouchpay.NewClientWithOptions("shop", "0788123456", "pw", Intouchpay.WithHTTPClient(stub))
+	assert.Equal(t, Intouchpay.EnvironmentProduction, client.Environment())
+
+	// A sandbox client never reaches production, and an explicit production mark lets real
+	// credentials that look like test ones through
+	client = Intouchpay.NewClientWithOptions("shop", "0788123456", "pw", Intouchpay.WithHTTPClient(stub), Intouchpay.WithEnvironment(Intouchpay.EnvironmentSandbox))
+	var out map[string]interface{}
+	err := client.Call(context.Background(), Intouchpay.GetBalanceEndpoint, nil, &out)
+	assert.ErrorIs(t, err, Intouchpay.ErrProductionGuard)
+	assert.NoError(t, balance("test_user", "1234567890", Intouchpay.WithEnvironment(Intouchpay.EnvironmentProduction)))
+
+	// The credentials of clients without a Username come from the Authenticator, once
+	auth := &CountingAuthenticator{Creds: Intouchpay.Credentials{Username: "demo_account"}}
+	client = Intouchpay.NewClientWithAuth(auth, Intouchpay.WithHTTPClient(stub))
+	for i := 0; i < 3; i++ {
+		_, err = client.GetBalance()
+		assert.ErrorIs(t, err, Intouchpay.ErrProductionGuard)
+	}
+	assert.Equal(t, 4, auth.Calls(), "one check plus one call per request body")
+
+	auth = &CountingAuthenticator{Creds: Intouchpay.Credentials{Username: "shop"}}
+	client = Intouchpay.NewClientWithAuth(auth, Intouchpay.WithHTTPClient(stub))
+	client.AccountNo = "0788123456"
+	for i := 0; i < 3; i++ {
+		_, err = client.GetBalance()
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 4, auth.Calls(), "one check plus one call per request body")
+
+	// Requesters set with WithHTTPClientInterface have no known URL and are not checked
+	mock := &MockHTTPClient{Response: &map[string]interface{}{"success": true}}
+	client = Intouchpay.NewClientWithOptions("test_user", "1234567890", "pw", Intouchpay.WithHTTPClientInterface(mock))
+	_, err = client.GetBalance()
+	assert.NoError(t, err)
+	assert.True(t, mock.Called)
+}
+
+// CountingAuthenticator returns fixed credentials and counts the calls
+type CountingAuthenticator struct {
+	Creds Intouchpay.Credentials
+	mu    sync.Mutex
+	calls int
+}
+
+// Authenticate returns the fixed credentials
+func (a *CountingAuthenticator) Authenticate() Intouchpay.Credentials {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.calls++
+	return a.Creds
+}
+
+// Calls returns the number of Authenticate calls
+func (a *CountingAuthenticator) Calls() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.calls
+}
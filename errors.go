@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 )
 
 // APIError represents an error returned by the IntouchPay API
@@ -12,6 +13,7 @@ type APIError struct {
 	Status     string
 	Response   map[string]interface{}
 	Message    string
+	Header     http.Header // Response headers, when the error came from an HTTP response
 }
 
 // Error implements the error interface
@@ -108,6 +110,36 @@ func IsMarshalError(err error) bool {
 	return ok
 }
 
+// UnexpectedFieldsError is returned by clients created with WithStrictDecoding when a
+// response has fields the library does not know or fields of an unexpected JSON type.
+// The decoded response is still available from the Extra and Raw of the response.
+type UnexpectedFieldsError struct {
+	Type     string   // Name of the response type
+	Unknown  []string // Fields that match no struct field
+	Mistyped []string // Fields whose value could not be decoded into the struct field
+}
+
+// Error implements the error interface
+func (e *UnexpectedFieldsError) Error() string {
+	msg := fmt.Sprintf("unexpected fields in %s:", e.Type)
+	if len(e.Unknown) > 0 {
+		msg += fmt.Sprintf(" unknown %s", strings.Join(e.Unknown, ", "))
+	}
+	if len(e.Unknown) > 0 && len(e.Mistyped) > 0 {
+		msg += ";"
+	}
+	if len(e.Mistyped) > 0 {
+		msg += fmt.Sprintf(" mistyped %s", strings.Join(e.Mistyped, ", "))
+	}
+	return msg
+}
+
+// IsUnexpectedFieldsError checks if an error is an UnexpectedFieldsError
+func IsUnexpectedFieldsError(err error) bool {
+	_, ok := err.(*UnexpectedFieldsError)
+	return ok
+}
+
 // HTTPStatus returns the HTTP status code if the error is an APIError
 func HTTPStatus(err error) int {
 	if apiErr, ok := err.(*APIError); ok {
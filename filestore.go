@@ -0,0 +1,126 @@
+package Intouchpay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+)
+
+// FileStore is a TransactionStore backed by an append-only JSON lines file. Every Save
+// appends the full record, so the file doubles as an audit log; the latest line for a
+// request transaction ID wins when the file is loaded. Records are indexed in memory.
+type FileStore struct {
+	mu    sync.Mutex
+	file  *os.File
+	index *MemoryStore
+}
+
+// OpenFileStore opens or creates the JSON lines file at path and loads its records
+func OpenFileStore(path string) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transaction store: %w", err)
+	}
+	s := &FileStore{file: file, index: NewMemoryStore()}
+	if err := s.load(); err != nil {
+		if cerr := file.Close(); cerr != nil {
+			log.Printf("warning: failed to close transaction store: %v", cerr)
+		}
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads every line of the file into the index. A truncated last line, left by a
+// crash in the middle of a write, is cut off so that the next record starts on a line of
+// its own.
+func (s *FileStore) load() error {
+	reader := bufio.NewReader(s.file)
+	var offset int64 // End of the last complete line
+	for lineNo := 1; ; lineNo++ {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) == 0 {
+				return nil
+			}
+			log.Printf("warning: removing truncated line %d from transaction store", lineNo)
+			if err := s.file.Truncate(offset); err != nil {
+				return fmt.Errorf("failed to truncate transaction store: %w", err)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read transaction store: %w", err)
+		}
+		offset += int64(len(line))
+		var record TransactionRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return NewMarshalError(fmt.Sprintf("transaction store line %d", lineNo), err)
+		}
+		s.index.records[record.RequestTransactionID] = &record
+	}
+}
+
+// Save appends the record to the file, syncs it to disk and updates the index
+func (s *FileStore) Save(ctx context.Context, record *TransactionRecord) error {
+	return s.write(ctx, record, false)
+}
+
+// Create appends the record like Save unless a transaction that has not failed is stored
+// under its ID, in which case it returns ErrTransactionExists
+func (s *FileStore) Create(ctx context.Context, record *TransactionRecord) error {
+	return s.write(ctx, record, true)
+}
+
+// write appends the record to the file, syncs it to disk and updates the index. With
+// create, the record is only written if MemoryStore.Create would accept it.
+func (s *FileStore) write(ctx context.Context, record *TransactionRecord, create bool) error {
+	if record.RequestTransactionID == "" {
+		return newValidationError("requesttransactionid", "record has no request transaction ID")
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return NewMarshalError("transaction record", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if create {
+		s.index.mu.RLock()
+		err := s.index.checkAbsent(record.RequestTransactionID)
+		s.index.mu.RUnlock()
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write transaction store: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync transaction store: %w", err)
+	}
+	return s.index.Save(ctx, record)
+}
+
+// Get returns a copy of the record for a request transaction ID
+func (s *FileStore) Get(ctx context.Context, requestTransactionID string) (*TransactionRecord, error) {
+	return s.index.Get(ctx, requestTransactionID)
+}
+
+// Find returns copies of the records matching query, oldest first
+func (s *FileStore) Find(ctx context.Context, query TransactionQuery) ([]*TransactionRecord, error) {
+	return s.index.Find(ctx, query)
+}
+
+// Close closes the underlying file
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
@@ -2,10 +2,14 @@ package Intouchpay
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"reflect"
 )
 
 // APIRequester defines the interface for making HTTP requests to the IntouchPay API
@@ -14,13 +18,40 @@ type APIRequester interface {
 	Do(endpoint string, body interface{}) (*map[string]interface{}, error)
 }
 
-// defaultHTTPClient implements APIRequester using net/http
+// ContextAPIRequester is an APIRequester that honours context cancellation and deadlines
+type ContextAPIRequester interface {
+	APIRequester
+	// DoContext sends a POST request to the given endpoint with the provided body.
+	// The request is aborted when ctx is cancelled or its deadline expires.
+	DoContext(ctx context.Context, endpoint string, body interface{}) (*map[string]interface{}, error)
+}
+
+// Requester sends requests and decodes the responses straight into typed values, without the
+// map round trip of APIRequester. The HTTP client returned by NewHTTPClient implements it;
+// AsRequester adapts any other APIRequester.
+type Requester interface {
+	// DoInto sends a POST request to the given endpoint with the provided body and decodes a
+	// successful response into out, which must be a pointer
+	DoInto(ctx context.Context, endpoint string, body, out interface{}) error
+}
+
+// Do sends a request through r and decodes the response into a new T
+func Do[T any](ctx context.Context, r Requester, endpoint string, body interface{}) (*T, error) {
+	out := new(T)
+	if err := r.DoInto(ctx, endpoint, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// defaultHTTPClient implements ContextAPIRequester and Requester using net/http
 type defaultHTTPClient struct {
 	client  *http.Client
 	baseURL string
 }
 
-// NewHTTPClient creates a new HTTP client with the provided configuration
+// NewHTTPClient creates a new HTTP client with the provided configuration.
+// The returned value also implements ContextAPIRequester and Requester.
 func NewHTTPClient(httpClient *http.Client, baseURL string) APIRequester {
 	return &defaultHTTPClient{
 		client:  httpClient,
@@ -30,23 +61,43 @@ func NewHTTPClient(httpClient *http.Client, baseURL string) APIRequester {
 
 // Do sends a POST request to the given endpoint with the provided body
 func (c *defaultHTTPClient) Do(endpoint string, body interface{}) (*map[string]interface{}, error) {
+	return c.DoContext(context.Background(), endpoint, body)
+}
+
+// DoContext sends a POST request to the given endpoint with the provided body. The decoded
+// error body of a non-200 response is returned along with the APIError.
+func (c *defaultHTTPClient) DoContext(ctx context.Context, endpoint string, body interface{}) (*map[string]interface{}, error) {
 	var response *map[string]interface{}
+	err := c.DoInto(ctx, endpoint, body, &response)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Response != nil {
+		response = &apiErr.Response
+	}
+	return response, err
+}
+
+// DoInto sends a POST request to the given endpoint and decodes a successful response
+// straight into out
+func (c *defaultHTTPClient) DoInto(ctx context.Context, endpoint string, body, out interface{}) error {
 	requestURL := c.baseURL + endpoint
 
 	jsonData, err := json.Marshal(body)
 	if err != nil {
-		return response, fmt.Errorf("failed to marshal request body: %w", err)
+		return fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, requestURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, requestURL, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return response, err
+		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if id := RequestIDFromContext(ctx); id != "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return response, err
+		return err
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
@@ -54,13 +105,125 @@ func (c *defaultHTTPClient) Do(endpoint string, body interface{}) (*map[string]i
 		}
 	}()
 
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return response, fmt.Errorf("IntouchPay API error: %d\n %s\n %w", resp.StatusCode, resp.Status, err)
+	if resp.StatusCode != http.StatusOK {
+		var errorBody map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&errorBody); err != nil {
+			return &APIError{
+				StatusCode: resp.StatusCode,
+				Status:     resp.Status,
+				Message:    "failed to parse error response",
+				Header:     resp.Header,
+			}
+		}
+		apiErr := newAPIError(resp.StatusCode, resp.Status, errorBody)
+		apiErr.Header = resp.Header
+		return apiErr
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return response, newAPIError(resp.StatusCode, resp.Status, *response)
+	var respBody io.Reader = resp.Body
+	if capture, ok := ctx.Value(rawBodyKey{}).(*rawBody); ok {
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read response body: %w", err)
+		}
+		capture.data = data
+		respBody = bytes.NewReader(data)
+	}
+	if err := json.NewDecoder(respBody).Decode(out); err != nil {
+		return fmt.Errorf("IntouchPay API error: %d\n %s\n %w", resp.StatusCode, resp.Status, err)
+	}
+	return nil
+}
+
+// rawBodyKey is the context key of the rawBody filled in by the HTTP client
+type rawBodyKey struct{}
+
+// rawBody receives the body of the last successful response read by the HTTP client, so that
+// requesterAdapter can decode the original bytes rather than the map its requester returns
+type rawBody struct {
+	data []byte
+}
+
+// contextAdapter lets a plain APIRequester be used where a ContextAPIRequester is expected.
+// The context is checked before the call is made but cannot interrupt it.
+type contextAdapter struct {
+	APIRequester
+}
+
+// DoContext checks ctx and then delegates to the wrapped requester's Do
+func (a contextAdapter) DoContext(ctx context.Context, endpoint string, body interface{}) (*map[string]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.Do(endpoint, body)
+}
+
+// AsContextRequester returns r as a ContextAPIRequester, adapting it if necessary.
+// Middlewares use it to pass the context on to the next requester.
+func AsContextRequester(r APIRequester) ContextAPIRequester {
+	if cr, ok := r.(ContextAPIRequester); ok {
+		return cr
 	}
+	return contextAdapter{APIRequester: r}
+}
+
+// requesterAdapter lets an APIRequester be used where a Requester is expected. When the map
+// response is the unchanged decode of a body read by the HTTP client further down, as with
+// middlewares that pass the context on, the original body is decoded into the target.
+// Otherwise the map is encoded again, which costs more and loses the precision of large
+// numbers.
+type requesterAdapter struct {
+	next ContextAPIRequester
+}
+
+// DoInto sends the request through the wrapped requester and converts its map response
+func (a requesterAdapter) DoInto(ctx context.Context, endpoint string, body, out interface{}) error {
+	capture := &rawBody{}
+	resp, err := a.next.DoContext(context.WithValue(ctx, rawBodyKey{}, capture), endpoint, body)
+	if err != nil {
+		return err
+	}
+	if capture.data != nil {
+		var original *map[string]interface{}
+		if json.Unmarshal(capture.data, &original) == nil && reflect.DeepEqual(original, resp) {
+			return json.Unmarshal(capture.data, out)
+		}
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return NewMarshalError("response", err)
+	}
+	return json.Unmarshal(data, out)
+}
+
+// AsRequester returns r as a Requester, adapting it if necessary. Mocks and other custom
+// APIRequester implementations keep working through the adapter.
+func AsRequester(r APIRequester) Requester {
+	if tr, ok := r.(Requester); ok {
+		return tr
+	}
+	return requesterAdapter{next: AsContextRequester(r)}
+}
+
+// mapRequester exposes a Requester as a ContextAPIRequester so that middlewares, which work
+// on map responses, can wrap it
+type mapRequester struct {
+	next Requester
+}
+
+// Do sends the request with a background context
+func (m mapRequester) Do(endpoint string, body interface{}) (*map[string]interface{}, error) {
+	return m.DoContext(context.Background(), endpoint, body)
+}
+
+// DoContext sends the request and decodes the response into a map
+func (m mapRequester) DoContext(ctx context.Context, endpoint string, body interface{}) (*map[string]interface{}, error) {
+	var response *map[string]interface{}
+	err := m.next.DoInto(ctx, endpoint, body, &response)
+	return response, err
+}
 
-	return response, nil
+// DoInto sends the request without going through a map
+func (m mapRequester) DoInto(ctx context.Context, endpoint string, body, out interface{}) error {
+	return m.next.DoInto(ctx, endpoint, body, out)
 }
@@ -1,9 +1,13 @@
 package Intouchpay_test
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -106,7 +110,7 @@ func TestMockHTTPClient(t *testing.T) {
 	client := Intouchpay.NewClientWithHTTPClient(auth, mockClient)
 
 	params := &Intouchpay.RequestPaymentParams{
-		Amount:               1000,
+		Amount:               Intouchpay.RWF(1000),
 		MobilePhone:          "0781234567",
 		RequestTransactionID: "TX123",
 	}
@@ -140,7 +144,7 @@ func TestRequestPaymentWithMockHTTP(t *testing.T) {
 	client.AccountNo = "ACC123"
 
 	params := &Intouchpay.RequestPaymentParams{
-		Amount:               5000,
+		Amount:               Intouchpay.RWF(5000),
 		MobilePhone:          "0781234567",
 		RequestTransactionID: "TX456",
 	}
@@ -176,7 +180,7 @@ func TestGetBalanceWithMockHTTP(t *testing.T) {
 
 	assert.NoError(t, err)
 	assert.True(t, resp.Success)
-	assert.Equal(t, 10000.50, resp.Balance)
+	assert.Equal(t, Intouchpay.MinorUnits(1000050), resp.Balance)
 }
 
 // TestWithHTTPClientInterfaceOption tests the WithHTTPClientInterface option
@@ -197,3 +201,120 @@ func TestWithHTTPClientInterfaceOption(t *testing.T) {
 	assert.NotNil(t, resp)
 	assert.True(t, mockClient.Called)
 }
+
+// TestHTTPClientDoContextCancelled tests that a cancelled context aborts the request
+func TestHTTPClientDoContextCancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	httpClient := &http.Client{Timeout: 5 * time.Second}
+	client := Intouchpay.NewHTTPClient(httpClient, server.URL).(Intouchpay.ContextAPIRequester)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.DoContext(ctx, "/test", map[string]string{"test": "value"})
+
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// TestGetBalanceContextWithPlainRequester tests that a cancelled context is honoured by non-context mocks
+func TestGetBalanceContextWithPlainRequester(t *testing.T) {
+	mockClient := &MockHTTPClient{Response: &map[string]interface{}{"success": true}}
+	auth := &MockAuthenticator{
+		Creds: Intouchpay.Credentials{
+			Username:  "test_user",
+			Timestamp: "20260320120000",
+			Password:  "test_hash",
+		},
+	}
+
+	client := Intouchpay.NewClientWithHTTPClient(auth, mockClient)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := client.GetBalanceContext(ctx)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, mockClient.Called)
+}
+
+// stubTransport answers every request with the same JSON body without touching the network
+type stubTransport struct {
+	body string
+}
+
+// RoundTrip returns the canned response
+func (s stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(s.body)),
+		Request:    req,
+	}, nil
+}
+
+// legacyRequester hides every method but Do, like a custom APIRequester written before
+// typed decoding existed
+type legacyRequester struct {
+	Intouchpay.APIRequester
+}
+
+// TestDoDecodesTypedResponse tests that the generic Do decodes straight into the target type
+func TestDoDecodesTypedResponse(t *testing.T) {
+	requester := Intouchpay.NewHTTPClient(&http.Client{Transport: stubTransport{body: `{"success":true,"balance":90071992547409.93}`}}, "http://intouchpay.test")
+
+	typed, ok := requester.(Intouchpay.Requester)
+	assert.True(t, ok)
+	resp, err := Intouchpay.Do[Intouchpay.BalanceResponse](context.Background(), typed, Intouchpay.GetBalanceEndpoint, nil)
+	assert.NoError(t, err)
+	assert.True(t, resp.Success)
+	assert.Equal(t, Intouchpay.MinorUnits(9007199254740993), resp.Balance, "no precision may be lost")
+}
+
+// TestAsRequesterAdaptsMapRequester tests that map-based requesters keep working through the adapter
+func TestAsRequesterAdaptsMapRequester(t *testing.T) {
+	mock := &MockHTTPClient{Response: &map[string]interface{}{"success": true, "balance": 1500.0}}
+	resp, err := Intouchpay.Do[Intouchpay.BalanceResponse](context.Background(), Intouchpay.AsRequester(mock), Intouchpay.GetBalanceEndpoint, nil)
+	assert.NoError(t, err)
+	assert.True(t, mock.Called)
+	assert.Equal(t, Intouchpay.RWF(1500), resp.Balance)
+
+	mock = &MockHTTPClient{Error: errors.New("boom")}
+	resp, err = Intouchpay.Do[Intouchpay.BalanceResponse](context.Background(), Intouchpay.AsRequester(mock), Intouchpay.GetBalanceEndpoint, nil)
+	assert.EqualError(t, err, "boom")
+	assert.Nil(t, resp)
+}
+
+// statusBody is a typical GetTransactionStatus response
+const statusBody = `{"success":true,"responsecode":1,"status":"Successfull","message":"Transaction Successfull"}`
+
+// BenchmarkGetTransactionStatus compares direct typed decoding with the map round trip that
+// custom APIRequester implementations still go through
+func BenchmarkGetTransactionStatus(b *testing.B) {
+	params := &Intouchpay.GetTransactionStatusParams{RequestTransactionID: "TX123", TransactionID: "9876543210"}
+	transport := Intouchpay.NewHTTPClient(&http.Client{Transport: stubTransport{body: statusBody}}, "http://intouchpay.test")
+	requesters := map[string]Intouchpay.APIRequester{
+		"typed":     transport,
+		"map_round": legacyRequester{transport},
+	}
+	for _, name := range []string{"typed", "map_round"} {
+		client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, requesters[name])
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := client.GetTransactionStatus(params); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,531 @@
+// Package intouchpaytest provides an in-process fake of the IntouchPay API for integration tests.
+// The fake verifies the SHA256 password scheme, keeps an in-memory ledger and balance, and
+// delivers payment callbacks, so the real HTTP path of Intouchpay.Client can be exercised end to end.
+package intouchpaytest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+)
+
+// Default credentials accepted by a Server created without WithCredentials
+const (
+	DefaultUsername        = "testuser"
+	DefaultAccountNo       = "250160000011"
+	DefaultPartnerPassword = "partner-secret"
+)
+
+// Transaction kinds recorded in the ledger
+const (
+	KindPayment = "payment"
+	KindDeposit = "deposit"
+)
+
+// Transaction statuses recorded in the ledger
+const (
+	StatusPending    = "Pending"
+	StatusSuccessful = "Successfull"
+	StatusFailed     = "Failed"
+)
+
+// Transaction is an entry in the fake server's ledger
+type Transaction struct {
+	Kind                 string
+	RequestTransactionID string
+	TransactionID        string
+	ReferenceID          string
+	Amount               Intouchpay.Money
+	MobilePhone          string
+	CallbackURL          string
+	Status               string
+	Code                 Intouchpay.ResponseCode
+	CreatedAt            time.Time
+}
+
+// Request is an authenticated request received by the fake server
+type Request struct {
+	Endpoint string
+	Body     map[string]interface{}
+}
+
+// RequestTransactionID returns the requesttransactionid field of the request body
+func (r *Request) RequestTransactionID() string {
+	return stringField(r.Body, "requesttransactionid")
+}
+
+// Amount returns the amount field of the request body, or zero if it is missing or malformed
+func (r *Request) Amount() Intouchpay.Money {
+	var amount Intouchpay.Money
+	data, err := json.Marshal(r.Body["amount"])
+	if err != nil {
+		return Intouchpay.Money{}
+	}
+	if err := amount.UnmarshalJSON(data); err != nil {
+		return Intouchpay.Money{}
+	}
+	return amount
+}
+
+// Outcome is a scripted response that replaces the server's default behaviour.
+// Scripted outcomes never touch the ledger.
+type Outcome struct {
+	Code       Intouchpay.ResponseCode
+	Message    string // Description of Code if empty
+	HTTPStatus int    // 200 if zero
+}
+
+// Hook inspects a request and returns an Outcome to script the response, or nil for the default behaviour
+type Hook func(req *Request) *Outcome
+
+// Server is an in-process fake of the IntouchPay API
+type Server struct {
+	*httptest.Server
+	Username        string
+	AccountNo       string
+	PartnerPassword string
+
+	mu           sync.Mutex
+	balance      Intouchpay.Money
+	transactions map[string]*Transaction
+	order        []string
+	nextID       int
+	hooks        map[string][]Hook
+	autoComplete *Intouchpay.ResponseCode
+	callbacks    sync.WaitGroup
+	callbackErrs []error
+	httpClient   *http.Client
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithCredentials sets the credentials the server accepts
+func WithCredentials(username, accountNo, partnerPassword string) Option {
+	return func(s *Server) {
+		s.Username = username
+		s.AccountNo = accountNo
+		s.PartnerPassword = partnerPassword
+	}
+}
+
+// WithBalance sets the opening account balance
+func WithBalance(balance Intouchpay.Money) Option {
+	return func(s *Server) {
+		s.balance = balance
+	}
+}
+
+// WithAutoComplete completes every payment request with code right after responding,
+// as if the subscriber had confirmed or rejected it immediately
+func WithAutoComplete(code Intouchpay.ResponseCode) Option {
+	return func(s *Server) {
+		s.autoComplete = &code
+	}
+}
+
+// NewServer starts a fake IntouchPay server. Call Close when done.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		Username:        DefaultUsername,
+		AccountNo:       DefaultAccountNo,
+		PartnerPassword: DefaultPartnerPassword,
+		transactions:    make(map[string]*Transaction),
+		hooks:           make(map[string][]Hook),
+		httpClient:      &http.Client{Timeout: 5 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(Intouchpay.RequestPaymentEndpoint, s.handle(Intouchpay.RequestPaymentEndpoint, s.requestPayment))
+	mux.HandleFunc(Intouchpay.RequestDepositEndpoint, s.handle(Intouchpay.RequestDepositEndpoint, s.requestDeposit))
+	mux.HandleFunc(Intouchpay.GetBalanceEndpoint, s.handle(Intouchpay.GetBalanceEndpoint, s.getBalance))
+	mux.HandleFunc(Intouchpay.GetTransactionStatusEndpoint, s.handle(Intouchpay.GetTransactionStatusEndpoint, s.getTransactionStatus))
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Close waits for pending callbacks and shuts the server down
+func (s *Server) Close() {
+	s.callbacks.Wait()
+	s.Server.Close()
+}
+
+// NewClient returns an Intouchpay.Client that talks to this server with its credentials. The
+// client is marked EnvironmentSandbox, so it can never reach production by mistake.
+func (s *Server) NewClient(opts ...Intouchpay.Option) *Intouchpay.Client {
+	opts = append([]Intouchpay.Option{
+		Intouchpay.WithHTTPClient(s.Client()),
+		Intouchpay.WithBaseURL(s.URL),
+		Intouchpay.WithEnvironment(Intouchpay.EnvironmentSandbox),
+	}, opts...)
+	return Intouchpay.NewClientWithOptions(s.Username, s.AccountNo, s.PartnerPassword, opts...)
+}
+
+// OnRequest registers a hook for endpoint. Hooks run in registration order and the first
+// non-nil Outcome is used.
+func (s *Server) OnRequest(endpoint string, hook Hook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks[endpoint] = append(s.hooks[endpoint], hook)
+}
+
+// FailNext makes the next request to endpoint fail with code
+func (s *Server) FailNext(endpoint string, code Intouchpay.ResponseCode) {
+	var once sync.Once
+	s.OnRequest(endpoint, func(_ *Request) *Outcome {
+		var outcome *Outcome
+		once.Do(func() {
+			outcome = &Outcome{Code: code}
+		})
+		return outcome
+	})
+}
+
+// Balance returns the current account balance
+func (s *Server) Balance() Intouchpay.Money {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.balance
+}
+
+// SetBalance replaces the current account balance
+func (s *Server) SetBalance(balance Intouchpay.Money) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.balance = balance
+}
+
+// Transaction returns the ledger entry for a request transaction ID
+func (s *Server) Transaction(requestTransactionID string) (Transaction, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx, ok := s.transactions[requestTransactionID]
+	if !ok {
+		return Transaction{}, false
+	}
+	return *tx, true
+}
+
+// Transactions returns every ledger entry in the order it was created
+func (s *Server) Transactions() []Transaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	txs := make([]Transaction, 0, len(s.order))
+	for _, id := range s.order {
+		txs = append(txs, *s.transactions[id])
+	}
+	return txs
+}
+
+// CallbackErrors returns the errors of callbacks that could not be delivered
+func (s *Server) CallbackErrors() []error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]error(nil), s.callbackErrs...)
+}
+
+// CompletePayment settles a pending payment with code, credits the balance on success and
+// delivers the callback synchronously
+func (s *Server) CompletePayment(requestTransactionID string, code Intouchpay.ResponseCode) error {
+	s.mu.Lock()
+	tx, ok := s.transactions[requestTransactionID]
+	if !ok || tx.Kind != KindPayment {
+		s.mu.Unlock()
+		return fmt.Errorf("intouchpaytest: no payment with request transaction ID %q", requestTransactionID)
+	}
+	if tx.Status != StatusPending {
+		s.mu.Unlock()
+		return fmt.Errorf("intouchpaytest: payment %q is already %s", requestTransactionID, tx.Status)
+	}
+	tx.Code = code
+	tx.ReferenceID = s.newID()
+	if code.Class() == Intouchpay.ClassSuccess {
+		tx.Status = StatusSuccessful
+		s.balance = s.balance.Add(tx.Amount)
+	} else {
+		tx.Status = StatusFailed
+	}
+	settled := *tx
+	s.mu.Unlock()
+
+	if settled.CallbackURL == "" {
+		return nil
+	}
+	return s.deliverCallback(settled)
+}
+
+// deliverCallback posts the callback for tx to its callback URL
+func (s *Server) deliverCallback(tx Transaction) error {
+	payload := map[string]interface{}{
+		"jsonpayload": Intouchpay.CallbackEvent{
+			RequestTransactionID: tx.RequestTransactionID,
+			TransactionID:        tx.TransactionID,
+			ResponseCode:         tx.Code,
+			Status:               tx.Status,
+			StatusDesc:           tx.Code.Description(),
+			ReferenceNo:          tx.ReferenceID,
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := s.httpClient.Post(tx.CallbackURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("intouchpaytest: failed to close callback response body: %v", closeErr)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("intouchpaytest: callback to %s returned %s", tx.CallbackURL, resp.Status)
+	}
+	return nil
+}
+
+// handle wraps an endpoint implementation with decoding, authentication and scripting hooks
+func (s *Server) handle(endpoint string, next func(req *Request) (int, map[string]interface{})) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{"success": false, "message": "invalid JSON"})
+			return
+		}
+		req := &Request{Endpoint: endpoint, Body: body}
+
+		if code, ok := s.authenticate(body); !ok {
+			writeJSON(w, http.StatusOK, failure(endpoint, code, ""))
+			return
+		}
+
+		if outcome := s.runHooks(req); outcome != nil {
+			status := outcome.HTTPStatus
+			if status == 0 {
+				status = http.StatusOK
+			}
+			writeJSON(w, status, failure(endpoint, outcome.Code, outcome.Message))
+			return
+		}
+
+		status, response := next(req)
+		writeJSON(w, status, response)
+	}
+}
+
+// authenticate verifies the username and SHA256 password of a request body
+func (s *Server) authenticate(body map[string]interface{}) (Intouchpay.ResponseCode, bool) {
+	username := stringField(body, "username")
+	password := stringField(body, "password")
+	timestamp := stringField(body, "timestamp")
+	switch {
+	case username == "":
+		return Intouchpay.CodeMissingUsername, false
+	case password == "":
+		return Intouchpay.CodeMissingPassword, false
+	case timestamp == "":
+		return Intouchpay.CodeMissingDate, false
+	case username != s.Username:
+		return Intouchpay.CodeNoSuchUser, false
+	}
+	if _, err := time.Parse("20060102150405", timestamp); err != nil {
+		return Intouchpay.CodeMissingDate, false
+	}
+	hash := sha256.Sum256([]byte(s.Username + s.AccountNo + s.PartnerPassword + timestamp))
+	if password != hex.EncodeToString(hash[:]) {
+		return Intouchpay.CodeInvalidPassword, false
+	}
+	return "", true
+}
+
+// runHooks returns the first scripted outcome for req, if any
+func (s *Server) runHooks(req *Request) *Outcome {
+	s.mu.Lock()
+	hooks := append([]Hook(nil), s.hooks[req.Endpoint]...)
+	s.mu.Unlock()
+	for _, hook := range hooks {
+		if outcome := hook(req); outcome != nil {
+			return outcome
+		}
+	}
+	return nil
+}
+
+// requestPayment records a pending payment
+func (s *Server) requestPayment(req *Request) (int, map[string]interface{}) {
+	requestID := req.RequestTransactionID()
+	if !req.Amount().IsPositive() {
+		return http.StatusOK, failure(req.Endpoint, Intouchpay.CodeAmountNotPositive, "")
+	}
+
+	s.mu.Lock()
+	if _, exists := s.transactions[requestID]; exists {
+		s.mu.Unlock()
+		return http.StatusOK, failure(req.Endpoint, Intouchpay.CodeDuplicateTransactionID, "")
+	}
+	tx := s.record(KindPayment, req)
+	tx.Status = StatusPending
+	tx.Code = Intouchpay.CodePending
+	autoComplete := s.autoComplete
+	s.mu.Unlock()
+
+	if autoComplete != nil {
+		s.callbacks.Add(1)
+		go func() {
+			defer s.callbacks.Done()
+			if err := s.CompletePayment(requestID, *autoComplete); err != nil {
+				s.mu.Lock()
+				s.callbackErrs = append(s.callbackErrs, err)
+				s.mu.Unlock()
+			}
+		}()
+	}
+
+	return http.StatusOK, map[string]interface{}{
+		"status":               StatusPending,
+		"requesttransactionid": requestID,
+		"success":              true,
+		"responsecode":         Intouchpay.CodePending.String(),
+		"transactionid":        tx.TransactionID,
+		"message":              "Transaction Pending",
+	}
+}
+
+// requestDeposit debits the balance and records a completed deposit
+func (s *Server) requestDeposit(req *Request) (int, map[string]interface{}) {
+	requestID := req.RequestTransactionID()
+	amount := req.Amount()
+	if !amount.IsPositive() {
+		return http.StatusOK, failure(req.Endpoint, Intouchpay.CodeInvalidAmountFormat, "")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.transactions[requestID]; exists {
+		return http.StatusOK, failure(req.Endpoint, Intouchpay.CodeDuplicateRemitID, "")
+	}
+	if amount.Cmp(s.balance) > 0 {
+		return http.StatusOK, failure(req.Endpoint, Intouchpay.CodeInsufficientAccountBalance, "")
+	}
+	s.balance = s.balance.Sub(amount)
+	tx := s.record(KindDeposit, req)
+	tx.Status = StatusSuccessful
+	tx.Code = Intouchpay.CodeDepositSuccessful
+	tx.ReferenceID = s.newID()
+
+	return http.StatusOK, map[string]interface{}{
+		"requesttransactionid": requestID,
+		"referenceid":          tx.ReferenceID,
+		"responsecode":         Intouchpay.CodeDepositSuccessful.String(),
+		"success":              true,
+	}
+}
+
+// getBalance reports the account balance
+func (s *Server) getBalance(_ *Request) (int, map[string]interface{}) {
+	return http.StatusOK, map[string]interface{}{
+		"balance": s.Balance(),
+		"success": true,
+	}
+}
+
+// getTransactionStatus reports the ledger status of a transaction
+func (s *Server) getTransactionStatus(req *Request) (int, map[string]interface{}) {
+	requestID := req.RequestTransactionID()
+	transactionID := stringField(req.Body, "transactionid")
+	if requestID == "" {
+		return http.StatusOK, failure(req.Endpoint, Intouchpay.CodeMissingRequestTransactionID, "")
+	}
+
+	tx, ok := s.Transaction(requestID)
+	if !ok || (transactionID != "" && tx.TransactionID != transactionID) {
+		return http.StatusOK, failure(req.Endpoint, Intouchpay.CodeTransactionNotFound, "")
+	}
+	return http.StatusOK, map[string]interface{}{
+		"success":      tx.Code.Class() != Intouchpay.ClassPermanentFailure && tx.Code.Class() != Intouchpay.ClassRetryableFailure,
+		"responsecode": codeValue(req.Endpoint, tx.Code),
+		"status":       tx.Status,
+		"message":      tx.Code.Description(),
+	}
+}
+
+// record adds a ledger entry for req. The caller must hold s.mu.
+func (s *Server) record(kind string, req *Request) *Transaction {
+	mobilePhone := stringField(req.Body, "mobilephone")
+	tx := &Transaction{
+		Kind:                 kind,
+		RequestTransactionID: req.RequestTransactionID(),
+		TransactionID:        s.newID(),
+		Amount:               req.Amount(),
+		MobilePhone:          mobilePhone,
+		CallbackURL:          stringField(req.Body, "callbackurl"),
+		CreatedAt:            time.Now().UTC(),
+	}
+	s.transactions[tx.RequestTransactionID] = tx
+	s.order = append(s.order, tx.RequestTransactionID)
+	return tx
+}
+
+// newID returns the next sequential identifier. The caller must hold s.mu.
+func (s *Server) newID() string {
+	s.nextID++
+	return strconv.Itoa(s.nextID)
+}
+
+// failure builds an unsuccessful response body for endpoint
+func failure(endpoint string, code Intouchpay.ResponseCode, message string) map[string]interface{} {
+	if message == "" {
+		message = code.Description()
+	}
+	return map[string]interface{}{
+		"success":      false,
+		"responsecode": codeValue(endpoint, code),
+		"message":      message,
+	}
+}
+
+// codeValue encodes code the way endpoint sends it: a number for balance and status
+// queries, a string otherwise
+func codeValue(endpoint string, code Intouchpay.ResponseCode) interface{} {
+	if endpoint == Intouchpay.GetBalanceEndpoint || endpoint == Intouchpay.GetTransactionStatusEndpoint {
+		if n, err := strconv.Atoi(code.String()); err == nil {
+			return n
+		}
+	}
+	return code.String()
+}
+
+// stringField returns body[key] if it is a string
+func stringField(body map[string]interface{}, key string) string {
+	if value, ok := body[key].(string); ok {
+		return value
+	}
+	return ""
+}
+
+// writeJSON writes body as a JSON response
+func writeJSON(w http.ResponseWriter, status int, body map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("intouchpaytest: failed to write response: %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package intouchpaytest_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+	"github.com/samueltuyizere/go-intouchpay/intouchpaytest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPaymentLifecycle tests a payment from request through callback to status query
+func TestPaymentLifecycle(t *testing.T) {
+	events := make(chan *Intouchpay.CallbackEvent, 1)
+	callbackServer := httptest.NewServer(Intouchpay.NewCallbackHandler(func(_ context.Context, event *Intouchpay.CallbackEvent) error {
+		events <- event
+		return nil
+	}))
+	defer callbackServer.Close()
+
+	server := intouchpaytest.NewServer(intouchpaytest.WithBalance(Intouchpay.RWF(1000)))
+	defer server.Close()
+	client := server.NewClient(Intouchpay.WithCallbackURL(callbackServer.URL))
+
+	resp, err := client.RequestPayment(&Intouchpay.RequestPaymentParams{
+		Amount:               Intouchpay.RWF(500),
+		MobilePhone:          "0781234567",
+		RequestTransactionID: "TX1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.CodePending, resp.Code())
+
+	tx, ok := server.Transaction("TX1")
+	assert.True(t, ok)
+	assert.Equal(t, "250781234567", tx.MobilePhone)
+	assert.Equal(t, intouchpaytest.StatusPending, tx.Status)
+
+	assert.NoError(t, server.CompletePayment("TX1", Intouchpay.CodeSuccessful))
+	event := <-events
+	assert.Equal(t, "TX1", event.RequestTransactionID)
+	assert.Equal(t, resp.TransactionID, event.TransactionID)
+	assert.Equal(t, Intouchpay.CodeSuccessful, event.Code())
+	assert.Equal(t, Intouchpay.RWF(1500), server.Balance())
+
+	status, err := client.GetTransactionStatus(&Intouchpay.GetTransactionStatusParams{
+		RequestTransactionID: "TX1",
+		TransactionID:        resp.TransactionID,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.CodeSuccessful, status.Code())
+}
+
+// TestAutoCompleteFiresCallback tests that WithAutoComplete settles payments on its own
+func TestAutoCompleteFiresCallback(t *testing.T) {
+	events := make(chan *Intouchpay.CallbackEvent, 1)
+	callbackServer := httptest.NewServer(Intouchpay.NewCallbackHandler(func(_ context.Context, event *Intouchpay.CallbackEvent) error {
+		events <- event
+		return nil
+	}))
+	defer callbackServer.Close()
+
+	server := intouchpaytest.NewServer(intouchpaytest.WithAutoComplete(Intouchpay.CodeInsufficientFunds))
+	defer server.Close()
+	client := server.NewClient(Intouchpay.WithCallbackURL(callbackServer.URL))
+
+	_, err := client.RequestPayment(&Intouchpay.RequestPaymentParams{
+		Amount:               Intouchpay.RWF(500),
+		MobilePhone:          "0721234567",
+		RequestTransactionID: "TX2",
+	})
+	assert.NoError(t, err)
+
+	event := <-events
+	assert.Equal(t, Intouchpay.CodeInsufficientFunds, event.Code())
+	assert.Equal(t, intouchpaytest.StatusFailed, event.Status)
+	assert.Empty(t, server.CallbackErrors())
+}
+
+// TestDepositLedger tests balance checks and duplicate detection for deposits
+func TestDepositLedger(t *testing.T) {
+	server := intouchpaytest.NewServer(intouchpaytest.WithBalance(Intouchpay.RWF(1000)))
+	defer server.Close()
+	client := server.NewClient()
+
+	params := &Intouchpay.RequestDepositParams{
+		Amount:               Intouchpay.RWF(600),
+		MobilePhone:          "0781234567",
+		RequestTransactionID: "D1",
+	}
+	resp, err := client.RequestDeposit(params)
+	assert.NoError(t, err)
+	assert.True(t, resp.Success)
+	assert.Equal(t, Intouchpay.CodeDepositSuccessful, resp.Code())
+	assert.NotEmpty(t, resp.ReferenceID)
+
+	resp, err = client.RequestDeposit(params)
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.CodeDuplicateRemitID, resp.Code())
+
+	params.RequestTransactionID = "D2"
+	resp, err = client.RequestDeposit(params)
+	assert.NoError(t, err)
+	assert.False(t, resp.Success)
+	assert.Equal(t, Intouchpay.CodeInsufficientAccountBalance, resp.Code())
+
+	balance, err := client.GetBalance()
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.RWF(400), balance.Balance)
+	assert.Len(t, server.Transactions(), 1)
+}
+
+// TestScriptedOutcomes tests FailNext and OnRequest hooks
+func TestScriptedOutcomes(t *testing.T) {
+	server := intouchpaytest.NewServer()
+	defer server.Close()
+	client := server.NewClient()
+
+	server.FailNext(Intouchpay.RequestPaymentEndpoint, Intouchpay.CodeDuplicateTransactionID)
+	server.OnRequest(Intouchpay.RequestDepositEndpoint, func(req *intouchpaytest.Request) *intouchpaytest.Outcome {
+		if req.Amount().Cmp(Intouchpay.RWF(100)) > 0 {
+			return &intouchpaytest.Outcome{Code: Intouchpay.CodeDailyLimitExceeded}
+		}
+		return nil
+	})
+
+	payment := &Intouchpay.RequestPaymentParams{Amount: Intouchpay.RWF(100), MobilePhone: "0781234567", RequestTransactionID: "TX3"}
+	resp, err := client.RequestPayment(payment)
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.CodeDuplicateTransactionID, resp.Code())
+
+	resp, err = client.RequestPayment(payment)
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.CodePending, resp.Code())
+
+	deposit, err := client.RequestDeposit(&Intouchpay.RequestDepositParams{
+		Amount: Intouchpay.RWF(500), MobilePhone: "0781234567", RequestTransactionID: "D3",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.CodeDailyLimitExceeded, deposit.Code())
+}
+
+// TestRejectsWrongPassword tests that the SHA256 password is verified
+func TestRejectsWrongPassword(t *testing.T) {
+	server := intouchpaytest.NewServer()
+	defer server.Close()
+
+	client := Intouchpay.NewClientWithOptions(
+		server.Username, server.AccountNo, "wrong-password",
+		Intouchpay.WithHTTPClientInterface(Intouchpay.NewHTTPClient(server.Client(), server.URL)),
+	)
+
+	balance, err := client.GetBalance()
+	assert.NoError(t, err)
+	assert.False(t, balance.Success)
+	assert.Equal(t, Intouchpay.CodeInvalidPassword, balance.Code())
+	assert.Equal(t, Intouchpay.ClassAuthFailure, balance.Code().Class())
+}
@@ -0,0 +1,151 @@
+package Intouchpay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Redacted replaces secret values in logs
+const Redacted = "[REDACTED]"
+
+// redactFields returns body as a map with passwords replaced by Redacted and phone numbers
+// masked. Any field whose name contains "password" or "phone" is treated as secret.
+func redactFields(body interface{}) map[string]interface{} {
+	var fields map[string]interface{}
+	switch b := body.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		fields = make(map[string]interface{}, len(b))
+		for k, v := range b {
+			fields[k] = v
+		}
+	case *map[string]interface{}:
+		if b == nil {
+			return nil
+		}
+		return redactFields(*b)
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil
+		}
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil
+		}
+	}
+
+	for key, value := range fields {
+		name := strings.ToLower(key)
+		switch {
+		case strings.Contains(name, "password"):
+			fields[key] = Redacted
+		case strings.Contains(name, "phone"):
+			fields[key] = maskDigits(fmt.Sprint(value))
+		}
+	}
+	return fields
+}
+
+// maskDigits replaces all but the last three digits of s with '*'
+func maskDigits(s string) string {
+	digits := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	masked := []rune(s)
+	for i, r := range masked {
+		if r >= '0' && r <= '9' && digits > 3 {
+			masked[i] = '*'
+			digits--
+		}
+	}
+	return string(masked)
+}
+
+// logRequester logs every API call to a slog.Logger
+type logRequester struct {
+	next   Requester
+	logger *slog.Logger
+}
+
+// DoInto sends the request and logs the endpoint, latency, HTTP status, response code and
+// request transaction ID. At debug level the redacted request and response bodies are logged too.
+// Successful calls log at info, failure responses at warn and transport errors or 5xx at error.
+func (r *logRequester) DoInto(ctx context.Context, endpoint string, body, out interface{}) error {
+	start := time.Now()
+	err := r.next.DoInto(ctx, endpoint, body, out)
+	latency := time.Since(start)
+
+	request := redactFields(body)
+	attrs := []slog.Attr{
+		slog.String("endpoint", endpoint),
+		slog.Duration("latency", latency),
+	}
+	if id := RequestIDFromContext(ctx); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	if id, ok := request["requesttransactionid"]; ok && id != "" {
+		attrs = append(attrs, slog.Any("requesttransactionid", id))
+	}
+
+	level := slog.LevelInfo
+	status := http.StatusOK
+	var apiErr *APIError
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.StatusCode
+		level = slog.LevelWarn
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+	case err != nil:
+		status = 0
+		level = slog.LevelError
+	}
+	if status != 0 {
+		attrs = append(attrs, slog.Int("http_status", status))
+	}
+
+	var response interface{}
+	switch {
+	case err == nil:
+		response = out
+	case apiErr != nil:
+		response = &apiErr.Response
+	}
+	code, success, hasSuccess := responseSummary(response)
+	if code != "" {
+		attrs = append(attrs, slog.String("responsecode", code.String()))
+	}
+	if hasSuccess && !success && level < slog.LevelWarn {
+		level = slog.LevelWarn
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	if r.logger.Enabled(ctx, slog.LevelDebug) {
+		attrs = append(attrs, slog.Any("request", request), slog.Any("response", redactFields(response)))
+	}
+
+	r.logger.LogAttrs(ctx, level, "intouchpay request", attrs...)
+	return err
+}
+
+// warnf logs a non-fatal problem to the client's logger, or the standard logger without one
+func (c *Client) warnf(format string, args ...interface{}) {
+	if c.logger != nil {
+		c.logger.Warn(fmt.Sprintf(format, args...))
+		return
+	}
+	log.Printf("warning: "+format, args...)
+}
@@ -0,0 +1,70 @@
+package Intouchpay_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+	"github.com/stretchr/testify/assert"
+)
+
+// jsonLogger returns a debug-level JSON logger writing to out
+func jsonLogger(out *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+// lastEntry decodes the last JSON log line in out
+func lastEntry(t *testing.T, out *bytes.Buffer) map[string]interface{} {
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	var entry map[string]interface{}
+	assert.NoError(t, json.Unmarshal(lines[len(lines)-1], &entry))
+	return entry
+}
+
+// TestWithLoggerRedactsSecrets tests the logged fields and the redaction of passwords and phones
+func TestWithLoggerRedactsSecrets(t *testing.T) {
+	var out bytes.Buffer
+	mock := &MockHTTPClient{Response: &map[string]interface{}{"success": true, "responsecode": "1000", "status": "Pending"}}
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{Creds: Intouchpay.Credentials{Password: "secret-hash"}}, mock,
+		Intouchpay.WithLogger(jsonLogger(&out)))
+
+	_, err := client.RequestPayment(&Intouchpay.RequestPaymentParams{Amount: Intouchpay.RWF(100), MobilePhone: "0781234567", RequestTransactionID: "P1"})
+	assert.NoError(t, err)
+
+	entry := lastEntry(t, &out)
+	assert.Equal(t, "INFO", entry["level"])
+	assert.Equal(t, Intouchpay.RequestPaymentEndpoint, entry["endpoint"])
+	assert.Equal(t, "P1", entry["requesttransactionid"])
+	assert.Equal(t, "1000", entry["responsecode"])
+	assert.Equal(t, float64(http.StatusOK), entry["http_status"])
+	assert.Contains(t, entry, "latency")
+	request, ok := entry["request"].(map[string]interface{})
+	assert.True(t, ok)
+	assert.Equal(t, Intouchpay.Redacted, request["password"])
+	assert.Equal(t, "*********567", request["mobilephone"])
+	assert.NotContains(t, out.String(), "secret-hash")
+	assert.NotContains(t, out.String(), "250781234567")
+}
+
+// TestWithLoggerLevels tests that the level follows the outcome of the call
+func TestWithLoggerLevels(t *testing.T) {
+	var out bytes.Buffer
+	mock := &MockHTTPClient{Response: &map[string]interface{}{"success": false, "responsecode": 1005}}
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, mock, Intouchpay.WithLogger(jsonLogger(&out)))
+
+	_, err := client.GetBalance()
+	assert.NoError(t, err)
+	assert.Equal(t, "WARN", lastEntry(t, &out)["level"])
+
+	mock.Response = nil
+	mock.Error = &Intouchpay.APIError{StatusCode: http.StatusBadGateway}
+	_, err = client.GetBalance()
+	assert.Error(t, err)
+	entry := lastEntry(t, &out)
+	assert.Equal(t, "ERROR", entry["level"])
+	assert.Equal(t, float64(http.StatusBadGateway), entry["http_status"])
+	assert.Contains(t, entry, "error")
+}
@@ -1,7 +1,8 @@
 package Intouchpay
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"net/http"
 )
 
@@ -11,14 +12,12 @@ func NewClient(username, accountNumber, partnerPassword, callbackURL string, sid
 	auth := NewAuthenticator(username, accountNumber, partnerPassword)
 	httpClient := &http.Client{Timeout: DefaultTimeout}
 	c := &Client{
-		Username:        username,
-		AccountNo:       accountNumber,
-		PartnerPassword: partnerPassword,
-		CallbackURL:     callbackURL,
-		Sid:             sid,
-		auth:            auth,
-		HTTPClient:      httpClient,
-		httpClient:      NewHTTPClient(httpClient, BaseURL),
+		Username:    username,
+		AccountNo:   accountNumber,
+		CallbackURL: callbackURL,
+		Sid:         sid,
+		auth:        auth,
+		HTTPClient:  httpClient,
 	}
 	return c
 }
@@ -30,7 +29,6 @@ func NewClientWithAuth(auth Authenticator, opts ...Option) *Client {
 	c := &Client{
 		auth:       auth,
 		HTTPClient: httpClient,
-		httpClient: NewHTTPClient(httpClient, BaseURL),
 	}
 	for _, opt := range opts {
 		opt(c)
@@ -44,12 +42,10 @@ func NewClientWithOptions(username, accountNumber, partnerPassword string, opts
 	auth := NewAuthenticator(username, accountNumber, partnerPassword)
 	httpClient := &http.Client{Timeout: DefaultTimeout}
 	c := &Client{
-		Username:        username,
-		AccountNo:       accountNumber,
-		PartnerPassword: partnerPassword,
-		auth:            auth,
-		HTTPClient:      httpClient,
-		httpClient:      NewHTTPClient(httpClient, BaseURL),
+		Username:   username,
+		AccountNo:  accountNumber,
+		auth:       auth,
+		HTTPClient: httpClient,
 	}
 	for _, opt := range opts {
 		opt(c)
@@ -72,50 +68,106 @@ func NewClientWithHTTPClient(auth Authenticator, httpClient APIRequester, opts .
 
 // RequestPayment initiates a payment request
 func (c *Client) RequestPayment(params *RequestPaymentParams) (*RequestPaymentResponse, error) {
-	phoneNumber, err := SanitizePhoneNumber(params.MobilePhone)
+	return c.RequestPaymentContext(context.Background(), params)
+}
+
+// RequestPaymentContext initiates a payment request.
+// The HTTP call is aborted when ctx is cancelled or its deadline expires.
+func (c *Client) RequestPaymentContext(ctx context.Context, params *RequestPaymentParams) (*RequestPaymentResponse, error) {
+	ctx, span := c.startSpan(ctx, "RequestPayment", RequestPaymentEndpoint,
+		Attribute{Key: AttrRequestTransactionID, Value: params.RequestTransactionID},
+		Attribute{Key: AttrAmount, Value: params.Amount.Francs()},
+		Attribute{Key: AttrOperator, Value: phoneOperator(params.MobilePhone)},
+	)
+	resp, err := c.requestPayment(ctx, params)
+	code := ResponseCode("")
+	if resp != nil {
+		code = resp.Code()
+	}
+	endSpan(span, code, err)
+	return resp, err
+}
+
+// requestPayment sends a payment request and records it in the transaction store
+func (c *Client) requestPayment(ctx context.Context, params *RequestPaymentParams) (*RequestPaymentResponse, error) {
+	phone, err := ParsePhoneNumber(params.MobilePhone)
 	if err != nil {
 		return nil, err
 	}
+	if err := validateAmount(params.Amount); err != nil {
+		return nil, err
+	}
 
-	creds := c.auth.Authenticate()
+	creds, err := c.credentials()
+	if err != nil {
+		return nil, err
+	}
 	requestBody := RequestPaymentBody{
 		Username:             creds.Username,
 		Timestamp:            creds.Timestamp,
 		Amount:               params.Amount,
 		Password:             creds.Password,
-		MobilePhone:          phoneNumber,
+		MobilePhone:          phone.API(),
 		RequestTransactionID: params.RequestTransactionID,
-		AccountNo:            c.AccountNo,
+		AccountNo:            creds.AccountNo,
 	}
 	if c.CallbackURL != "" {
 		requestBody.CallbackURL = c.CallbackURL
 	}
 
-	var cResp *RequestPaymentResponse
-	resp, err := c.httpClient.Do(RequestPaymentEndpoint, requestBody)
-	if err != nil {
-		return cResp, err
-	}
-	respBytes, err := json.Marshal(resp)
+	record, err := c.beginRecord(ctx, TransactionPayment, params.RequestTransactionID, phone.API(), "", params.Amount)
 	if err != nil {
-		return nil, NewMarshalError("request payment response", err)
+		return nil, err
 	}
-	err = json.Unmarshal(respBytes, &cResp)
+
+	c.traceLinks.remember(params.RequestTransactionID, ctx)
+	cResp, err := Do[RequestPaymentResponse](ctx, c.requester(), RequestPaymentEndpoint, requestBody)
 	if err != nil {
-		return cResp, err
+		c.traceLinks.forgetUnsent(params.RequestTransactionID, err)
+		c.finishRecord(ctx, record, "", "", "", err)
+		return nil, err
 	}
 
+	c.finishRecord(ctx, record, cResp.TransactionID, "", cResp.Code(), nil)
 	return cResp, nil
 }
 
 // RequestDeposit initiates a deposit request
 func (c *Client) RequestDeposit(params *RequestDepositParams) (*RequestDepositResponse, error) {
-	phoneNumber, err := SanitizePhoneNumber(params.MobilePhone)
+	return c.RequestDepositContext(context.Background(), params)
+}
+
+// RequestDepositContext initiates a deposit request.
+// The HTTP call is aborted when ctx is cancelled or its deadline expires.
+func (c *Client) RequestDepositContext(ctx context.Context, params *RequestDepositParams) (*RequestDepositResponse, error) {
+	ctx, span := c.startSpan(ctx, "RequestDeposit", RequestDepositEndpoint,
+		Attribute{Key: AttrRequestTransactionID, Value: params.RequestTransactionID},
+		Attribute{Key: AttrAmount, Value: params.Amount.Francs()},
+		Attribute{Key: AttrOperator, Value: phoneOperator(params.MobilePhone)},
+	)
+	resp, err := c.requestDeposit(ctx, params)
+	code := ResponseCode("")
+	if resp != nil {
+		code = resp.Code()
+	}
+	endSpan(span, code, err)
+	return resp, err
+}
+
+// requestDeposit sends a deposit request and records it in the transaction store
+func (c *Client) requestDeposit(ctx context.Context, params *RequestDepositParams) (*RequestDepositResponse, error) {
+	phone, err := ParsePhoneNumber(params.MobilePhone)
 	if err != nil {
 		return nil, err
 	}
+	if err := validateAmount(params.Amount); err != nil {
+		return nil, err
+	}
 
-	creds := c.auth.Authenticate()
+	creds, err := c.credentials()
+	if err != nil {
+		return nil, err
+	}
 	requestBody := RequestDepositBody{
 		Username:             creds.Username,
 		Timestamp:            creds.Timestamp,
@@ -124,58 +176,103 @@ func (c *Client) RequestDeposit(params *RequestDepositParams) (*RequestDepositRe
 		Reason:               params.Reason,
 		Sid:                  c.Sid,
 		Password:             creds.Password,
-		MobilePhoneNo:        phoneNumber,
+		MobilePhoneNo:        phone.API(),
 		RequestTransactionID: params.RequestTransactionID,
-		AccountNo:            c.AccountNo,
+		AccountNo:            creds.AccountNo,
 	}
 
-	var cResp *RequestDepositResponse
-	resp, err := c.httpClient.Do(RequestDepositEndpoint, requestBody)
+	record, err := c.beginRecord(ctx, TransactionDeposit, params.RequestTransactionID, phone.API(), params.Reason, params.Amount)
 	if err != nil {
-		return cResp, err
-	}
-	respBytes, err := json.Marshal(resp)
-	if err != nil {
-		return nil, NewMarshalError("request deposit response", err)
+		return nil, err
 	}
-	err = json.Unmarshal(respBytes, &cResp)
+
+	c.traceLinks.remember(params.RequestTransactionID, ctx)
+	cResp, err := Do[RequestDepositResponse](ctx, c.requester(), RequestDepositEndpoint, requestBody)
 	if err != nil {
-		return cResp, err
+		c.traceLinks.forgetUnsent(params.RequestTransactionID, err)
+		c.finishRecord(ctx, record, "", "", "", err)
+		return nil, err
 	}
 
+	c.finishRecord(ctx, record, "", cResp.ReferenceID, cResp.Code(), nil)
 	return cResp, nil
 }
 
 // GetBalance queries account balance
 func (c *Client) GetBalance() (*BalanceResponse, error) {
-	creds := c.auth.Authenticate()
-	requestBody := GetBalanceBody{
-		Username:  creds.Username,
-		Timestamp: creds.Timestamp,
-		AccountNo: c.AccountNo,
-		Password:  creds.Password,
-	}
+	return c.GetBalanceContext(context.Background())
+}
 
-	var cResp *BalanceResponse
-	resp, err := c.httpClient.Do(GetBalanceEndpoint, requestBody)
-	if err != nil {
-		return cResp, err
+// GetBalanceContext queries account balance.
+// The HTTP call is aborted when ctx is cancelled or its deadline expires.
+func (c *Client) GetBalanceContext(ctx context.Context) (*BalanceResponse, error) {
+	ctx, span := c.startSpan(ctx, "GetBalance", GetBalanceEndpoint)
+	resp, err := c.getBalance(ctx)
+	code := ResponseCode("")
+	if resp != nil {
+		code = resp.Code()
 	}
-	respBytes, err := json.Marshal(resp)
+	endSpan(span, code, err)
+	return resp, err
+}
+
+// getBalance queries the balance and records it in the metrics
+func (c *Client) getBalance(ctx context.Context) (*BalanceResponse, error) {
+	body, err := c.balanceBody()
 	if err != nil {
-		return nil, NewMarshalError("balance response", err)
+		return nil, err
 	}
-	err = json.Unmarshal(respBytes, &cResp)
+	cResp, err := Do[BalanceResponse](ctx, c.requester(), GetBalanceEndpoint, body)
 	if err != nil {
-		return cResp, err
+		return nil, err
 	}
 
+	if c.metrics != nil && cResp.Success {
+		c.metrics.SetBalance(cResp.Balance)
+	}
 	return cResp, nil
 }
 
+// balanceBody builds the request body for GetBalance with fresh credentials
+func (c *Client) balanceBody() (GetBalanceBody, error) {
+	creds, err := c.credentials()
+	if err != nil {
+		return GetBalanceBody{}, err
+	}
+	return GetBalanceBody{
+		Username:  creds.Username,
+		Timestamp: creds.Timestamp,
+		AccountNo: creds.AccountNo,
+		Password:  creds.Password,
+	}, nil
+}
+
 // GetTransactionStatus queries the status of a transaction
 func (c *Client) GetTransactionStatus(params *GetTransactionStatusParams) (*GetTransactionStatusResponse, error) {
-	creds := c.auth.Authenticate()
+	return c.GetTransactionStatusContext(context.Background(), params)
+}
+
+// GetTransactionStatusContext queries the status of a transaction.
+// The HTTP call is aborted when ctx is cancelled or its deadline expires.
+func (c *Client) GetTransactionStatusContext(ctx context.Context, params *GetTransactionStatusParams) (*GetTransactionStatusResponse, error) {
+	ctx, span := c.startSpan(ctx, "GetTransactionStatus", GetTransactionStatusEndpoint,
+		Attribute{Key: AttrRequestTransactionID, Value: params.RequestTransactionID},
+	)
+	resp, err := c.getTransactionStatus(ctx, params)
+	code := ResponseCode("")
+	if resp != nil {
+		code = resp.Code()
+	}
+	endSpan(span, code, err)
+	return resp, err
+}
+
+// getTransactionStatus queries the status of a transaction and records it in the transaction store
+func (c *Client) getTransactionStatus(ctx context.Context, params *GetTransactionStatusParams) (*GetTransactionStatusResponse, error) {
+	creds, err := c.credentials()
+	if err != nil {
+		return nil, err
+	}
 	requestBody := GetTransactionStatusBody{
 		Username:             creds.Username,
 		Timestamp:            creds.Timestamp,
@@ -184,25 +281,116 @@ func (c *Client) GetTransactionStatus(params *GetTransactionStatusParams) (*GetT
 		Password:             creds.Password,
 	}
 
-	var cResp *GetTransactionStatusResponse
-	resp, err := c.httpClient.Do(GetTransactionStatusEndpoint, requestBody)
-	if err != nil {
-		return cResp, err
-	}
-	respBytes, err := json.Marshal(resp)
+	cResp, err := Do[GetTransactionStatusResponse](ctx, c.requester(), GetTransactionStatusEndpoint, requestBody)
 	if err != nil {
-		return nil, NewMarshalError("transaction status response", err)
+		return nil, err
 	}
-	err = json.Unmarshal(respBytes, &cResp)
-	if err != nil {
-		return cResp, err
+
+	err = c.recordUpdate(ctx, params.RequestTransactionID, params.TransactionID, "", SourceStatus, cResp.Code(), cResp.Status)
+	if err != nil && !errors.Is(err, ErrTransactionNotFound) {
+		c.warnf("failed to record status of transaction %s: %v", params.RequestTransactionID, err)
 	}
 
 	return cResp, nil
 }
 
+// credentials returns fresh credentials for one request. AccountNo is the account number the
+// password was hashed with, or the client's when the Authenticator does not report it.
+func (c *Client) credentials() (Credentials, error) {
+	var creds Credentials
+	if auth, ok := c.auth.(fallibleAuthenticator); ok {
+		var err error
+		if creds, err = auth.authenticate(); err != nil {
+			return Credentials{}, err
+		}
+	} else {
+		creds = c.auth.Authenticate()
+	}
+	if creds.AccountNo == "" {
+		creds.AccountNo = c.AccountNo
+	}
+	return creds, nil
+}
+
+// refreshCredentials returns a copy of a request body built by the client with a new timestamp
+// and password, for a retry. Other bodies are returned unchanged.
+func (c *Client) refreshCredentials(body interface{}) (interface{}, error) {
+	switch body.(type) {
+	case RequestPaymentBody, RequestDepositBody, GetBalanceBody, GetTransactionStatusBody, map[string]interface{}:
+	default:
+		return body, nil
+	}
+	creds, err := c.credentials()
+	if err != nil {
+		return nil, err
+	}
+	switch b := body.(type) {
+	case RequestPaymentBody:
+		b.Username, b.Timestamp, b.Password, b.AccountNo = creds.Username, creds.Timestamp, creds.Password, creds.AccountNo
+		return b, nil
+	case RequestDepositBody:
+		b.Username, b.Timestamp, b.Password, b.AccountNo = creds.Username, creds.Timestamp, creds.Password, creds.AccountNo
+		return b, nil
+	case GetBalanceBody:
+		b.Username, b.Timestamp, b.Password, b.AccountNo = creds.Username, creds.Timestamp, creds.Password, creds.AccountNo
+		return b, nil
+	case GetTransactionStatusBody:
+		b.Username, b.Timestamp, b.Password = creds.Username, creds.Timestamp, creds.Password
+		return b, nil
+	case map[string]interface{}:
+		// Built by Call; the account number may come from its params and is kept
+		m := make(map[string]interface{}, len(b))
+		for k, v := range b {
+			m[k] = v
+		}
+		m["username"], m["timestamp"], m["password"] = creds.Username, creds.Timestamp, creds.Password
+		return m, nil
+	default:
+		return body, nil
+	}
+}
+
 // GetAuthCredentials returns the current authentication credentials.
 // This is primarily useful for testing.
 func (c *Client) GetAuthCredentials() Credentials {
 	return c.auth.Authenticate()
 }
+
+// requester returns the client's transport wrapped in its request pipeline.
+// Middlewares are outermost, so they see each call once whatever the number of retries.
+// When the production guard trips, every call fails before reaching the pipeline.
+func (c *Client) requester() Requester {
+	if err := c.checkProduction(); err != nil {
+		return refusedRequester{err: err}
+	}
+	r := c.transport()
+	if c.strictDecoding {
+		r = &strictRequester{next: r}
+	}
+	if c.tracer != nil {
+		r = &traceRequester{next: r, tracer: c.tracer}
+	}
+	if c.metrics != nil {
+		r = &metricsRequester{next: r, metrics: c.metrics}
+	}
+	if c.logger != nil {
+		r = &logRequester{next: r, logger: c.logger}
+	}
+	if c.rateLimiter != nil {
+		r = &rateLimitRequester{next: r, limiter: c.rateLimiter}
+	}
+	if c.breaker != nil {
+		r = &circuitRequester{next: r, breaker: c.breaker, metrics: c.metrics, probeBody: func() (interface{}, error) { return c.balanceBody() }}
+	}
+	if c.retryPolicy != nil && c.retryPolicy.MaxAttempts > 1 {
+		r = &retryRequester{next: r, policy: *c.retryPolicy, metrics: c.metrics, refresh: c.refreshCredentials}
+	}
+	if len(c.middlewares) == 0 {
+		return r
+	}
+	var m ContextAPIRequester = mapRequester{next: r}
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		m = AsContextRequester(c.middlewares[i](m))
+	}
+	return AsRequester(m)
+}
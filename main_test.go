@@ -40,8 +40,8 @@ func TestNewClientWithValidInputParameters(t *testing.T) {
 		t.Errorf("Expected account number %s, but got %s", accountNumber, client.AccountNo)
 	}
 
-	if client.PartnerPassword != partnerPassword {
-		t.Errorf("Expected password %s, but got %s", partnerPassword, client.PartnerPassword)
+	if client.PartnerPassword != "" {
+		t.Errorf("Expected the partner password to be kept off the client, but got %s", client.PartnerPassword)
 	}
 
 	if client.CallbackURL != callbackURL {
@@ -173,7 +173,7 @@ func TestNewClientWithOptions(t *testing.T) {
 	assert.NotNil(t, client)
 	assert.Equal(t, "testuser", client.Username)
 	assert.Equal(t, "1234567890", client.AccountNo)
-	assert.Equal(t, "password", client.PartnerPassword)
+	assert.Empty(t, client.PartnerPassword)
 	assert.Equal(t, "https://example.com/callback", client.CallbackURL)
 	assert.Equal(t, 12345, client.Sid)
 	assert.Equal(t, 30*time.Second, client.HTTPClient.Timeout)
@@ -0,0 +1,266 @@
+package Intouchpay
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OutcomeError is the request outcome for calls that got no decodable answer
+const OutcomeError = "error"
+
+// MetricsRecorder receives metrics about API calls. Implementations must be safe for
+// concurrent use. PrometheusMetrics is a built-in implementation.
+type MetricsRecorder interface {
+	// ObserveRequest records one HTTP call. outcome is the CodeClass name of the response
+	// code, or OutcomeError.
+	ObserveRequest(endpoint, outcome string, duration time.Duration)
+	// IncRetry records a retry of a call to endpoint
+	IncRetry(endpoint string)
+	// IncCircuitRejected records a call to endpoint refused with ErrCircuitOpen
+	IncCircuitRejected(endpoint string)
+	// SetCircuitState records the circuit breaker state
+	SetCircuitState(state CircuitState)
+	// SetBalance records the balance returned by a successful GetBalance call
+	SetBalance(balance Money)
+}
+
+// requestOutcome classifies the result of an HTTP call decoded into out for metrics
+func requestOutcome(out interface{}, err error) string {
+	if err != nil {
+		return OutcomeError
+	}
+	code, success, _ := responseSummary(out)
+	if class := code.Class(); class != ClassUnknown {
+		return class.String()
+	}
+	if success {
+		return ClassSuccess.String()
+	}
+	return ClassUnknown.String()
+}
+
+// metricsRequester records the outcome and duration of every HTTP call
+type metricsRequester struct {
+	next    Requester
+	metrics MetricsRecorder
+}
+
+// DoInto sends the request and records it
+func (r *metricsRequester) DoInto(ctx context.Context, endpoint string, body, out interface{}) error {
+	start := time.Now()
+	err := r.next.DoInto(ctx, endpoint, body, out)
+	r.metrics.ObserveRequest(endpoint, requestOutcome(out, err), time.Since(start))
+	return err
+}
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the latency histogram buckets
+var DefaultLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// otherEndpoint is the endpoint label of calls to endpoints the library has no method for,
+// so that Client.Call cannot create an unbounded number of series
+const otherEndpoint = "other"
+
+// endpointLabel returns the endpoint label for a call to endpoint
+func endpointLabel(endpoint string) string {
+	switch endpoint {
+	case RequestPaymentEndpoint, RequestDepositEndpoint, GetBalanceEndpoint, GetTransactionStatusEndpoint:
+		return endpoint
+	default:
+		return otherEndpoint
+	}
+}
+
+// PrometheusMetrics is a MetricsRecorder that serves its metrics in the Prometheus text
+// exposition format. Calls to endpoints other than the four documented ones, made with
+// Client.Call, share the endpoint label "other". Mount it on a mux to let Prometheus scrape it:
+//
+//	metrics := Intouchpay.NewPrometheusMetrics(nil)
+//	http.Handle("/metrics", metrics)
+type PrometheusMetrics struct {
+	mu               sync.Mutex
+	buckets          []float64
+	requests         map[[2]string]uint64 // By endpoint and outcome
+	latencies        map[string]*histogram
+	retries          map[string]uint64
+	circuitRejected  map[string]uint64
+	circuitState     CircuitState
+	balance          Money
+	balanceUpdatedAt time.Time
+}
+
+// histogram holds the latency observations of one endpoint
+type histogram struct {
+	counts []uint64 // One per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewPrometheusMetrics creates an empty PrometheusMetrics with the given latency buckets in
+// seconds, or DefaultLatencyBuckets when nil
+func NewPrometheusMetrics(buckets []float64) *PrometheusMetrics {
+	if buckets == nil {
+		buckets = DefaultLatencyBuckets
+	}
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return &PrometheusMetrics{
+		buckets:         sorted,
+		requests:        make(map[[2]string]uint64),
+		latencies:       make(map[string]*histogram),
+		retries:         make(map[string]uint64),
+		circuitRejected: make(map[string]uint64),
+	}
+}
+
+// ObserveRequest implements MetricsRecorder
+func (m *PrometheusMetrics) ObserveRequest(endpoint, outcome string, duration time.Duration) {
+	endpoint = endpointLabel(endpoint)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[[2]string{endpoint, outcome}]++
+	h, ok := m.latencies[endpoint]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(m.buckets))}
+		m.latencies[endpoint] = h
+	}
+	seconds := duration.Seconds()
+	h.count++
+	h.sum += seconds
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+}
+
+// IncRetry implements MetricsRecorder
+func (m *PrometheusMetrics) IncRetry(endpoint string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries[endpointLabel(endpoint)]++
+}
+
+// IncCircuitRejected implements MetricsRecorder
+func (m *PrometheusMetrics) IncCircuitRejected(endpoint string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.circuitRejected[endpointLabel(endpoint)]++
+}
+
+// SetCircuitState implements MetricsRecorder
+func (m *PrometheusMetrics) SetCircuitState(state CircuitState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.circuitState = state
+}
+
+// SetBalance implements MetricsRecorder
+func (m *PrometheusMetrics) SetBalance(balance Money) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.balance = balance
+	m.balanceUpdatedAt = time.Now()
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if _, err := m.WriteTo(w); err != nil {
+		log.Printf("warning: failed to write metrics: %v", err)
+	}
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString("# HELP intouchpay_requests_total API calls by endpoint and outcome class.\n")
+	b.WriteString("# TYPE intouchpay_requests_total counter\n")
+	keys := make([][2]string, 0, len(m.requests))
+	for key := range m.requests {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	for _, key := range keys {
+		fmt.Fprintf(&b, "intouchpay_requests_total{endpoint=%s,outcome=%s} %d\n", quoteLabel(key[0]), quoteLabel(key[1]), m.requests[key])
+	}
+
+	b.WriteString("# HELP intouchpay_request_duration_seconds API call latency by endpoint.\n")
+	b.WriteString("# TYPE intouchpay_request_duration_seconds histogram\n")
+	for _, endpoint := range sortedKeys(m.latencies) {
+		h := m.latencies[endpoint]
+		label := quoteLabel(endpoint)
+		var cumulative uint64
+		for i, bound := range m.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(&b, "intouchpay_request_duration_seconds_bucket{endpoint=%s,le=\"%s\"} %d\n", label, formatFloat(bound), cumulative)
+		}
+		fmt.Fprintf(&b, "intouchpay_request_duration_seconds_bucket{endpoint=%s,le=\"+Inf\"} %d\n", label, h.count)
+		fmt.Fprintf(&b, "intouchpay_request_duration_seconds_sum{endpoint=%s} %s\n", label, formatFloat(h.sum))
+		fmt.Fprintf(&b, "intouchpay_request_duration_seconds_count{endpoint=%s} %d\n", label, h.count)
+	}
+
+	writeCounter(&b, "intouchpay_retries_total", "Retries by endpoint.", m.retries)
+	writeCounter(&b, "intouchpay_circuit_rejections_total", "Calls refused by the open circuit breaker by endpoint.", m.circuitRejected)
+
+	b.WriteString("# HELP intouchpay_circuit_state Circuit breaker state: 0 closed, 1 open, 2 half-open.\n")
+	b.WriteString("# TYPE intouchpay_circuit_state gauge\n")
+	fmt.Fprintf(&b, "intouchpay_circuit_state %d\n", m.circuitState)
+
+	if !m.balanceUpdatedAt.IsZero() {
+		b.WriteString("# HELP intouchpay_balance Last known account balance.\n")
+		b.WriteString("# TYPE intouchpay_balance gauge\n")
+		fmt.Fprintf(&b, "intouchpay_balance %s\n", formatFloat(m.balance.Float64()))
+		b.WriteString("# HELP intouchpay_balance_updated_timestamp_seconds Time of the last balance update.\n")
+		b.WriteString("# TYPE intouchpay_balance_updated_timestamp_seconds gauge\n")
+		fmt.Fprintf(&b, "intouchpay_balance_updated_timestamp_seconds %d\n", m.balanceUpdatedAt.Unix())
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// writeCounter writes a counter family labelled by endpoint
+func writeCounter(b *strings.Builder, name, help string, values map[string]uint64) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(b, "# TYPE %s counter\n", name)
+	for _, endpoint := range sortedKeys(values) {
+		fmt.Fprintf(b, "%s{endpoint=%s} %d\n", name, quoteLabel(endpoint), values[endpoint])
+	}
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// quoteLabel quotes a label value, escaping backslashes, quotes and newlines
+func quoteLabel(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
+// formatFloat formats a sample value
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
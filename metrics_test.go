@@ -0,0 +1,88 @@
+package Intouchpay_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+	"github.com/stretchr/testify/assert"
+)
+
+// scrape returns the text exposition of metrics
+func scrape(t *testing.T, metrics *Intouchpay.PrometheusMetrics) string {
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, recorder.Header().Get("Content-Type"), "text/plain")
+	return recorder.Body.String()
+}
+
+// TestPrometheusMetricsRequestsAndRetries tests request, latency, retry and balance metrics
+func TestPrometheusMetricsRequestsAndRetries(t *testing.T) {
+	metrics := Intouchpay.NewPrometheusMetrics([]float64{0.1, 1})
+	requester := &SequenceHTTPClient{
+		Responses: []*map[string]interface{}{nil, {"success": true, "responsecode": 1000, "status": "Pending"}, {"success": true, "balance": 2500}},
+		Errors:    []error{&Intouchpay.APIError{StatusCode: http.StatusBadGateway}},
+	}
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, requester,
+		Intouchpay.WithMetrics(metrics),
+		Intouchpay.WithRetryPolicy(Intouchpay.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}),
+	)
+
+	_, err := client.GetTransactionStatus(&Intouchpay.GetTransactionStatusParams{RequestTransactionID: "TX1"})
+	assert.NoError(t, err)
+	_, err = client.GetBalance()
+	assert.NoError(t, err)
+
+	out := scrape(t, metrics)
+	status := `endpoint="` + Intouchpay.GetTransactionStatusEndpoint + `"`
+	assert.Contains(t, out, `intouchpay_requests_total{`+status+`,outcome="error"} 1`)
+	assert.Contains(t, out, `intouchpay_requests_total{`+status+`,outcome="pending"} 1`)
+	assert.Contains(t, out, `intouchpay_requests_total{endpoint="`+Intouchpay.GetBalanceEndpoint+`",outcome="success"} 1`)
+	assert.Contains(t, out, `intouchpay_request_duration_seconds_bucket{`+status+`,le="0.1"} 2`)
+	assert.Contains(t, out, `intouchpay_request_duration_seconds_bucket{`+status+`,le="+Inf"} 2`)
+	assert.Contains(t, out, `intouchpay_request_duration_seconds_count{`+status+`} 2`)
+	assert.Contains(t, out, `intouchpay_retries_total{`+status+`} 1`)
+	assert.Contains(t, out, "intouchpay_balance 2500\n")
+	assert.Contains(t, out, "# TYPE intouchpay_request_duration_seconds histogram")
+}
+
+// TestPrometheusMetricsOtherEndpoints tests that endpoints called with Call share one label
+func TestPrometheusMetricsOtherEndpoints(t *testing.T) {
+	metrics := Intouchpay.NewPrometheusMetrics(nil)
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, &MockHTTPClient{Response: &map[string]interface{}{"success": true}},
+		Intouchpay.WithMetrics(metrics),
+	)
+
+	for _, endpoint := range []string{"/getcommission/", "/a/", "/b/"} {
+		var out map[string]interface{}
+		assert.NoError(t, client.Call(context.Background(), endpoint, nil, &out))
+	}
+
+	out := scrape(t, metrics)
+	assert.Contains(t, out, `intouchpay_requests_total{endpoint="other",outcome="success"} 3`)
+	assert.NotContains(t, out, "/getcommission/")
+}
+
+// TestPrometheusMetricsCircuitBreaker tests the circuit breaker counters
+func TestPrometheusMetricsCircuitBreaker(t *testing.T) {
+	metrics := Intouchpay.NewPrometheusMetrics(nil)
+	breaker := Intouchpay.NewCircuitBreaker(&Intouchpay.CircuitBreakerOptions{FailureThreshold: 1, OpenTimeout: time.Hour})
+	requester := &OutageHTTPClient{Err: &Intouchpay.APIError{StatusCode: http.StatusServiceUnavailable}}
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, requester,
+		Intouchpay.WithMetrics(metrics),
+		Intouchpay.WithCircuitBreaker(breaker),
+	)
+
+	for i := 0; i < 3; i++ {
+		_, err := client.GetBalance()
+		assert.Error(t, err)
+	}
+
+	out := scrape(t, metrics)
+	assert.Contains(t, out, `intouchpay_circuit_rejections_total{endpoint="`+Intouchpay.GetBalanceEndpoint+`"} 2`)
+	assert.Contains(t, out, "intouchpay_circuit_state 1\n")
+	assert.NotContains(t, out, "intouchpay_balance ")
+}
@@ -0,0 +1,114 @@
+package Intouchpay
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Middleware wraps an APIRequester to add behaviour around every API call. The requester
+// passed to a middleware always implements ContextAPIRequester; use AsContextRequester to
+// call it with the caller's context.
+type Middleware func(next APIRequester) APIRequester
+
+// RequesterFunc adapts a function to a ContextAPIRequester
+type RequesterFunc func(ctx context.Context, endpoint string, body interface{}) (*map[string]interface{}, error)
+
+// Do calls f with a background context
+func (f RequesterFunc) Do(endpoint string, body interface{}) (*map[string]interface{}, error) {
+	return f(context.Background(), endpoint, body)
+}
+
+// DoContext calls f
+func (f RequesterFunc) DoContext(ctx context.Context, endpoint string, body interface{}) (*map[string]interface{}, error) {
+	return f(ctx, endpoint, body)
+}
+
+// RequestIDHeader is the HTTP header that carries the request ID
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey is the context key for request IDs
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a context carrying a request ID. The HTTP client sends it in
+// the RequestIDHeader header.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, or an empty string
+func RequestIDFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+		return id
+	}
+	return ""
+}
+
+// NewRequestID returns a random 32-character hex request ID
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// RequestIDMiddleware gives every call a request ID, unless the caller's context already
+// carries one. IDs come from generate, or NewRequestID when it is nil.
+func RequestIDMiddleware(generate func() string) Middleware {
+	if generate == nil {
+		generate = NewRequestID
+	}
+	return func(next APIRequester) APIRequester {
+		cnext := AsContextRequester(next)
+		return RequesterFunc(func(ctx context.Context, endpoint string, body interface{}) (*map[string]interface{}, error) {
+			if RequestIDFromContext(ctx) == "" {
+				ctx = ContextWithRequestID(ctx, generate())
+			}
+			return cnext.DoContext(ctx, endpoint, body)
+		})
+	}
+}
+
+// TimingMiddleware calls observe with the duration and error of every call
+func TimingMiddleware(observe func(endpoint string, duration time.Duration, err error)) Middleware {
+	return func(next APIRequester) APIRequester {
+		cnext := AsContextRequester(next)
+		return RequesterFunc(func(ctx context.Context, endpoint string, body interface{}) (*map[string]interface{}, error) {
+			start := time.Now()
+			resp, err := cnext.DoContext(ctx, endpoint, body)
+			observe(endpoint, time.Since(start), err)
+			return resp, err
+		})
+	}
+}
+
+// LoggingMiddleware logs the endpoint, request ID, duration and outcome of every call to
+// logger, or the standard logger when it is nil. Request bodies are not logged because they
+// contain the password.
+func LoggingMiddleware(logger *log.Logger) Middleware {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return func(next APIRequester) APIRequester {
+		cnext := AsContextRequester(next)
+		return RequesterFunc(func(ctx context.Context, endpoint string, body interface{}) (*map[string]interface{}, error) {
+			start := time.Now()
+			resp, err := cnext.DoContext(ctx, endpoint, body)
+			duration := time.Since(start).Round(time.Millisecond)
+			id := RequestIDFromContext(ctx)
+			switch {
+			case err != nil:
+				logger.Printf("intouchpay: %s request_id=%s duration=%s error=%v", endpoint, id, duration, err)
+			case resp != nil:
+				logger.Printf("intouchpay: %s request_id=%s duration=%s success=%v responsecode=%v", endpoint, id, duration, (*resp)["success"], (*resp)["responsecode"])
+			default:
+				logger.Printf("intouchpay: %s request_id=%s duration=%s", endpoint, id, duration)
+			}
+			return resp, err
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package Intouchpay_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+	"github.com/stretchr/testify/assert"
+)
+
+// tracingMiddleware records when it is entered and left, and what it saw
+func tracingMiddleware(name string, trace *[]string) Intouchpay.Middleware {
+	return func(next Intouchpay.APIRequester) Intouchpay.APIRequester {
+		cnext := Intouchpay.AsContextRequester(next)
+		return Intouchpay.RequesterFunc(func(ctx context.Context, endpoint string, body interface{}) (*map[string]interface{}, error) {
+			*trace = append(*trace, name+" "+endpoint)
+			resp, err := cnext.DoContext(ctx, endpoint, body)
+			*trace = append(*trace, name+" done")
+			if resp != nil {
+				*trace = append(*trace, fmt.Sprintf("%s saw %v", name, (*resp)["responsecode"]))
+			}
+			return resp, err
+		})
+	}
+}
+
+// TestMiddlewareOrder tests that middlewares run in order and see the endpoint and decoded response
+func TestMiddlewareOrder(t *testing.T) {
+	var trace []string
+	mock := &MockHTTPClient{Response: &map[string]interface{}{"success": true, "responsecode": "2001"}}
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, mock,
+		Intouchpay.WithMiddleware(tracingMiddleware("a", &trace)),
+		Intouchpay.WithMiddleware(tracingMiddleware("b", &trace)),
+	)
+
+	_, err := client.RequestDeposit(&Intouchpay.RequestDepositParams{Amount: Intouchpay.RWF(100), MobilePhone: "0781234567", RequestTransactionID: "D1"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"a " + Intouchpay.RequestDepositEndpoint,
+		"b " + Intouchpay.RequestDepositEndpoint,
+		"b done", "b saw 2001",
+		"a done", "a saw 2001",
+	}, trace)
+}
+
+// TestRequestIDMiddleware tests that request IDs reach the API as a header
+func TestRequestIDMiddleware(t *testing.T) {
+	var ids []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids = append(ids, r.Header.Get(Intouchpay.RequestIDHeader))
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "balance": 10}); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+	client := Intouchpay.NewClientWithAuth(&MockAuthenticator{},
+		Intouchpay.WithHTTPClientInterface(Intouchpay.NewHTTPClient(server.Client(), server.URL)),
+		Intouchpay.WithMiddleware(Intouchpay.RequestIDMiddleware(func() string { return "generated" })),
+	)
+
+	_, err := client.GetBalance()
+	assert.NoError(t, err)
+	_, err = client.GetBalanceContext(Intouchpay.ContextWithRequestID(context.Background(), "from-caller"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"generated", "from-caller"}, ids)
+}
+
+// TestLoggingAndTimingMiddleware tests the built-in logging and timing middlewares
+func TestLoggingAndTimingMiddleware(t *testing.T) {
+	var out bytes.Buffer
+	var timed []string
+	mock := &MockHTTPClient{Response: &map[string]interface{}{"success": false, "responsecode": "1005"}}
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{Creds: Intouchpay.Credentials{Password: "secret-hash"}}, mock,
+		Intouchpay.WithMiddleware(
+			Intouchpay.RequestIDMiddleware(func() string { return "req-1" }),
+			Intouchpay.LoggingMiddleware(log.New(&out, "", 0)),
+			Intouchpay.TimingMiddleware(func(endpoint string, duration time.Duration, err error) {
+				assert.NoError(t, err)
+				assert.GreaterOrEqual(t, duration, time.Duration(0))
+				timed = append(timed, endpoint)
+			}),
+		),
+	)
+
+	_, err := client.RequestPayment(&Intouchpay.RequestPaymentParams{Amount: Intouchpay.RWF(100), MobilePhone: "0781234567", RequestTransactionID: "P1"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{Intouchpay.RequestPaymentEndpoint}, timed)
+	assert.Contains(t, out.String(), Intouchpay.RequestPaymentEndpoint)
+	assert.Contains(t, out.String(), "request_id=req-1")
+	assert.Contains(t, out.String(), "responsecode=1005")
+	assert.NotContains(t, out.String(), "secret-hash")
+}
+
+// TestMiddlewareKeepsRawBody tests that responses passed through middlewares unchanged are
+// decoded from the body as received, and changed ones from the changed map
+func TestMiddlewareKeepsRawBody(t *testing.T) {
+	body := `{"success":true,"balance":2500,"fee":12345678901234567891}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+	transport := Intouchpay.NewHTTPClient(server.Client(), server.URL)
+
+	var trace []string
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, transport, Intouchpay.WithMiddleware(tracingMiddleware("a", &trace)))
+	resp, err := client.GetBalance()
+	assert.NoError(t, err)
+	assert.Equal(t, body, string(resp.Raw()))
+	assert.Equal(t, "12345678901234567891", string(resp.Extra["fee"]))
+
+	rewrite := func(next Intouchpay.APIRequester) Intouchpay.APIRequester {
+		cnext := Intouchpay.AsContextRequester(next)
+		return Intouchpay.RequesterFunc(func(ctx context.Context, endpoint string, body interface{}) (*map[string]interface{}, error) {
+			resp, err := cnext.DoContext(ctx, endpoint, body)
+			if resp != nil {
+				(*resp)["balance"] = 3000
+			}
+			return resp, err
+		})
+	}
+	client = Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, transport, Intouchpay.WithMiddleware(rewrite))
+	resp, err = client.GetBalance()
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.RWF(3000), resp.Balance)
+	assert.Contains(t, string(resp.Raw()), `"balance":3000`)
+}
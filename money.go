@@ -0,0 +1,289 @@
+package Intouchpay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// CurrencyRWF is the currency of every IntouchPay amount
+const CurrencyRWF = "RWF"
+
+// minorPerFranc is the number of minor units (centimes) in one Rwandan franc
+const minorPerFranc = 100
+
+// Money is an amount of Rwandan francs held as an integer number of minor units (centimes),
+// so sums and comparisons are exact. The zero value is 0 RWF. Build amounts with RWF or
+// ParseMoney; Money values can be compared with ==.
+type Money struct {
+	minor int64
+}
+
+// maxMinor is the largest amount in centimes a Money holds. Amounts saturate at it and at
+// its negation instead of wrapping around.
+const maxMinor = math.MaxInt64
+
+// RWF returns an amount of whole francs. Amounts beyond about ±92 quadrillion francs
+// (math.MaxInt64 centimes) saturate at that limit.
+func RWF(francs int64) Money {
+	return Money{minor: saturatingMul(francs, minorPerFranc)}
+}
+
+// MinorUnits returns an amount of centimes, the hundredth of a franc
+func MinorUnits(minor int64) Money {
+	if minor < -maxMinor {
+		minor = -maxMinor
+	}
+	return Money{minor: minor}
+}
+
+// saturatingMul returns a * b, or ±maxMinor when the product does not fit
+func saturatingMul(a, b int64) int64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	product := a * b
+	if product/b != a || product == math.MinInt64 || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		if (a < 0) == (b < 0) {
+			return maxMinor
+		}
+		return -maxMinor
+	}
+	return product
+}
+
+// saturatingAdd returns a + b, or ±maxMinor when the sum does not fit
+func saturatingAdd(a, b int64) int64 {
+	sum := a + b
+	switch {
+	case a > 0 && b > 0 && sum < 0:
+		return maxMinor
+	case a < 0 && b < 0 && (sum >= 0 || sum < -maxMinor):
+		return -maxMinor
+	}
+	return sum
+}
+
+// ParseMoney parses an amount such as "1500", "1,500 RWF", "RWF 1 500.50" or "-20". The
+// currency may be written RWF, FRW or RF, before or after the number, in any case. At most
+// two decimals are accepted.
+func ParseMoney(s string) (Money, error) {
+	return parseMoney(s, false)
+}
+
+// parseMoney parses an amount like ParseMoney. With round, decimals beyond the second are
+// rounded half away from zero instead of rejected.
+func parseMoney(s string, round bool) (Money, error) {
+	text := strings.ToUpper(strings.TrimSpace(s))
+	for _, currency := range []string{CurrencyRWF, "FRW", "RF"} {
+		if strings.HasPrefix(text, currency) {
+			text = text[len(currency):]
+			break
+		}
+		if strings.HasSuffix(text, currency) {
+			text = text[:len(text)-len(currency)]
+			break
+		}
+	}
+	text = strings.NewReplacer(",", "", " ", "", "_", "", "\u00a0", "").Replace(text)
+
+	negative := strings.HasPrefix(text, "-")
+	text = strings.TrimPrefix(strings.TrimPrefix(text, "-"), "+")
+	whole, fraction, hasFraction := strings.Cut(text, ".")
+	if (whole == "" && fraction == "") || !isDigits(whole) || !isDigits(fraction) || (hasFraction && fraction == "") {
+		return Money{}, newValidationError("amount", fmt.Sprintf("invalid amount %q", s))
+	}
+	roundUp := false
+	if trimmed := strings.TrimRight(fraction, "0"); len(trimmed) > 2 {
+		if !round {
+			return Money{}, newValidationError("amount", fmt.Sprintf("amount %q has more than two decimals", s))
+		}
+		roundUp = trimmed[2] >= '5'
+	}
+	fraction = (fraction + "00")[:2]
+
+	var francs int64
+	if whole != "" {
+		var err error
+		if francs, err = strconv.ParseInt(whole, 10, 64); err != nil || francs > (1<<63-1)/minorPerFranc-1 {
+			return Money{}, newValidationError("amount", fmt.Sprintf("amount %q is out of range", s))
+		}
+	}
+	cents, err := strconv.ParseInt(fraction, 10, 64)
+	if err != nil {
+		return Money{}, newValidationError("amount", fmt.Sprintf("invalid amount %q", s))
+	}
+	if roundUp {
+		cents++
+	}
+	m := Money{minor: francs*minorPerFranc + cents}
+	if negative {
+		m.minor = -m.minor
+	}
+	return m, nil
+}
+
+// validateAmount checks that a payment or deposit amount is a positive whole number of francs
+func validateAmount(amount Money) error {
+	if amount.minor == maxMinor {
+		return newValidationError("amount", "amount is out of range")
+	}
+	if !amount.IsPositive() || !amount.IsWhole() {
+		return newValidationError("amount", fmt.Sprintf("amount %s must be a positive whole number of francs", amount))
+	}
+	return nil
+}
+
+// isDigits reports whether s holds only ASCII digits
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// MinorUnits returns the amount in centimes
+func (m Money) MinorUnits() int64 {
+	return m.minor
+}
+
+// Francs returns the whole francs of the amount, truncated towards zero
+func (m Money) Francs() int64 {
+	return m.minor / minorPerFranc
+}
+
+// IsWhole reports whether the amount is a whole number of francs, as the API requires for
+// payments and deposits
+func (m Money) IsWhole() bool {
+	return m.minor%minorPerFranc == 0
+}
+
+// Float64 returns the amount in francs as a float, for display and metrics only
+func (m Money) Float64() float64 {
+	return float64(m.minor) / minorPerFranc
+}
+
+// Currency returns the currency code, always RWF
+func (m Money) Currency() string {
+	return CurrencyRWF
+}
+
+// Add returns m + o, saturating at the limits of Money
+func (m Money) Add(o Money) Money {
+	return Money{minor: saturatingAdd(m.minor, o.minor)}
+}
+
+// Sub returns m - o, saturating at the limits of Money
+func (m Money) Sub(o Money) Money {
+	return Money{minor: saturatingAdd(m.minor, -o.minor)}
+}
+
+// Mul returns m * n, saturating at the limits of Money
+func (m Money) Mul(n int64) Money {
+	return Money{minor: saturatingMul(m.minor, n)}
+}
+
+// Cmp returns -1, 0 or +1 when m is less than, equal to or greater than o
+func (m Money) Cmp(o Money) int {
+	switch {
+	case m.minor < o.minor:
+		return -1
+	case m.minor > o.minor:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.minor == 0
+}
+
+// IsNegative reports whether the amount is below zero
+func (m Money) IsNegative() bool {
+	return m.minor < 0
+}
+
+// IsPositive reports whether the amount is above zero
+func (m Money) IsPositive() bool {
+	return m.minor > 0
+}
+
+// number formats the amount in francs without grouping, such as 1500 or -1500.5
+func (m Money) number() string {
+	minor := m.minor
+	sign := ""
+	if minor < 0 {
+		sign, minor = "-", -minor
+	}
+	s := sign + strconv.FormatInt(minor/minorPerFranc, 10)
+	if cents := minor % minorPerFranc; cents != 0 {
+		s += strings.TrimRight(fmt.Sprintf(".%02d", cents), "0")
+	}
+	return s
+}
+
+// String formats the amount with thousands separators, such as "1,500 RWF" or "1,500.50 RWF"
+func (m Money) String() string {
+	minor := m.minor
+	sign := ""
+	if minor < 0 {
+		sign, minor = "-", -minor
+	}
+	digits := strconv.FormatInt(minor/minorPerFranc, 10)
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, r := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	if cents := minor % minorPerFranc; cents != 0 {
+		fmt.Fprintf(&b, ".%02d", cents)
+	}
+	return b.String() + " " + CurrencyRWF
+}
+
+// MarshalJSON encodes the amount as a number of francs, the way the API sends and expects it
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.number()), nil
+}
+
+// UnmarshalJSON decodes a number of francs or a string accepted by ParseMoney. The number is
+// read from its text, so it is not rounded through a float; decimals beyond the second are
+// rounded half away from zero. null and "" leave m unchanged.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	text := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		if strings.TrimSpace(text) == "" {
+			return nil
+		}
+	} else if strings.ContainsAny(text, "eE") {
+		// Exponent notation, as produced when a float64 is marshalled
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return newValidationError("amount", fmt.Sprintf("invalid amount %s", text))
+		}
+		text = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	parsed, err := parseMoney(text, true)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
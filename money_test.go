@@ -0,0 +1,140 @@
+package Intouchpay_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseMoney tests the accepted amount formats
+func TestParseMoney(t *testing.T) {
+	cases := map[string]Intouchpay.Money{
+		"1500":           Intouchpay.RWF(1500),
+		"1,500 RWF":      Intouchpay.RWF(1500),
+		"RWF 1 500":      Intouchpay.RWF(1500),
+		"1500 frw":       Intouchpay.RWF(1500),
+		"1,500.50 RWF":   Intouchpay.MinorUnits(150050),
+		"0.5":            Intouchpay.MinorUnits(50),
+		"10.500":         Intouchpay.MinorUnits(1050),
+		"-20":            Intouchpay.RWF(-20),
+		"1,000,000 RWF":  Intouchpay.RWF(1000000),
+		"2\u00a0500 RWF": Intouchpay.RWF(2500),
+	}
+	for input, want := range cases {
+		got, err := Intouchpay.ParseMoney(input)
+		if assert.NoError(t, err, input) {
+			assert.Equal(t, want, got, input)
+		}
+	}
+
+	for _, input := range []string{"", "RWF", "abc", "1.234", "1.", "1..5", "USD 10", "1e3"} {
+		_, err := Intouchpay.ParseMoney(input)
+		assert.True(t, Intouchpay.IsValidationError(err), input)
+	}
+}
+
+// TestMoneyArithmetic tests that sums and comparisons are exact
+func TestMoneyArithmetic(t *testing.T) {
+	total := Intouchpay.Money{}
+	for i := 0; i < 10; i++ {
+		total = total.Add(Intouchpay.MinorUnits(10))
+	}
+	assert.Equal(t, Intouchpay.RWF(1), total)
+	assert.Equal(t, Intouchpay.RWF(1500), Intouchpay.RWF(500).Mul(3))
+	assert.Equal(t, Intouchpay.RWF(-100), Intouchpay.RWF(400).Sub(Intouchpay.RWF(500)))
+	assert.Equal(t, -1, Intouchpay.RWF(1).Cmp(Intouchpay.RWF(2)))
+	assert.Equal(t, 0, Intouchpay.RWF(2).Cmp(Intouchpay.MinorUnits(200)))
+	assert.True(t, Intouchpay.RWF(-1).IsNegative())
+	assert.False(t, Intouchpay.MinorUnits(150).IsWhole())
+	assert.Equal(t, int64(1), Intouchpay.MinorUnits(150).Francs())
+	assert.Equal(t, 1.5, Intouchpay.MinorUnits(150).Float64())
+}
+
+// TestMoneyOverflow tests that amounts saturate instead of wrapping around
+func TestMoneyOverflow(t *testing.T) {
+	huge := Intouchpay.RWF(1 << 62)
+	assert.True(t, huge.IsPositive())
+	assert.Equal(t, int64(math.MaxInt64), huge.MinorUnits())
+	assert.NotEqual(t, "0 RWF", huge.String())
+	assert.Equal(t, int64(-math.MaxInt64), Intouchpay.RWF(-1<<62).MinorUnits())
+
+	assert.Equal(t, huge, Intouchpay.RWF(1<<40).Mul(1<<40))
+	assert.Equal(t, int64(-math.MaxInt64), Intouchpay.RWF(1<<40).Mul(-(1 << 40)).MinorUnits())
+	assert.Equal(t, huge, huge.Add(Intouchpay.RWF(1)))
+	assert.Equal(t, int64(-math.MaxInt64), Intouchpay.RWF(-1<<62).Sub(Intouchpay.RWF(1)).MinorUnits())
+	assert.Equal(t, Intouchpay.RWF(3000), Intouchpay.RWF(1500).Mul(2))
+
+	mock := &MockHTTPClient{}
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, mock)
+	_, err := client.RequestPayment(&Intouchpay.RequestPaymentParams{Amount: huge, MobilePhone: "0781234567", RequestTransactionID: "P1"})
+	assert.True(t, Intouchpay.IsValidationError(err))
+	assert.False(t, mock.Called)
+}
+
+// TestMoneyString tests the display format
+func TestMoneyString(t *testing.T) {
+	assert.Equal(t, "0 RWF", Intouchpay.Money{}.String())
+	assert.Equal(t, "1,500 RWF", Intouchpay.RWF(1500).String())
+	assert.Equal(t, "1,234,567.05 RWF", Intouchpay.MinorUnits(123456705).String())
+	assert.Equal(t, "-999 RWF", Intouchpay.RWF(-999).String())
+}
+
+// TestMoneyJSON tests that balances decode from numbers and strings without rounding
+func TestMoneyJSON(t *testing.T) {
+	var resp Intouchpay.BalanceResponse
+	for input, want := range map[string]Intouchpay.Money{
+		`{"balance":10000.1}`:          Intouchpay.MinorUnits(1000010),
+		`{"balance":"10000.10"}`:       Intouchpay.MinorUnits(1000010),
+		`{"balance":"1,500 RWF"}`:      Intouchpay.RWF(1500),
+		`{"balance":1e6}`:              Intouchpay.RWF(1000000),
+		`{"balance":9007199254740993}`: Intouchpay.RWF(9007199254740993),
+	} {
+		resp = Intouchpay.BalanceResponse{}
+		if assert.NoError(t, json.Unmarshal([]byte(input), &resp), input) {
+			assert.Equal(t, want, resp.Balance, input)
+		}
+	}
+	var m Intouchpay.Money
+	assert.Error(t, json.Unmarshal([]byte(`"lots"`), &m))
+
+	// Extra decimals are rounded and empty strings ignored rather than failing the response
+	for input, want := range map[string]Intouchpay.Money{
+		`{"balance":10000.125}`:      Intouchpay.MinorUnits(1000013),
+		`{"balance":"10000.1249"}`:   Intouchpay.MinorUnits(1000012),
+		`{"balance":-0.005}`:         Intouchpay.MinorUnits(-1),
+		`{"balance":0.999}`:          Intouchpay.RWF(1),
+		`{"balance":""}`:             {},
+		`{"balance":1.0000000001e3}`: Intouchpay.RWF(1000),
+	} {
+		resp = Intouchpay.BalanceResponse{}
+		if assert.NoError(t, json.Unmarshal([]byte(input), &resp), input) {
+			assert.Equal(t, want, resp.Balance, input)
+			assert.Empty(t, resp.Extra, input)
+		}
+	}
+	_, err := Intouchpay.ParseMoney("10000.125")
+	assert.True(t, Intouchpay.IsValidationError(err), "ParseMoney stays strict")
+
+	data, err := json.Marshal(Intouchpay.RequestPaymentBody{Amount: Intouchpay.RWF(1500)})
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"amount":1500,`)
+	data, err = json.Marshal(Intouchpay.MinorUnits(150050))
+	assert.NoError(t, err)
+	assert.Equal(t, "1500.5", string(data))
+}
+
+// TestRequestPaymentRejectsFractionalAmount tests that amounts the API cannot take are refused
+// before any call is made
+func TestRequestPaymentRejectsFractionalAmount(t *testing.T) {
+	mock := &MockHTTPClient{}
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, mock)
+
+	for _, amount := range []Intouchpay.Money{{}, Intouchpay.MinorUnits(150050), Intouchpay.RWF(-5)} {
+		_, err := client.RequestPayment(&Intouchpay.RequestPaymentParams{Amount: amount, MobilePhone: "0781234567", RequestTransactionID: "P1"})
+		assert.True(t, Intouchpay.IsValidationError(err), amount.String())
+	}
+	assert.False(t, mock.Called)
+}
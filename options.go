@@ -1,7 +1,9 @@
 package Intouchpay
 
 import (
+	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -25,6 +27,22 @@ func WithTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithBaseURL sends requests to url instead of BaseURL, for a sandbox or a local stand-in.
+// It has no effect when an APIRequester is set with WithHTTPClientInterface.
+func WithBaseURL(url string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(url, "/")
+	}
+}
+
+// WithEnvironment marks the client with env. A client marked EnvironmentSandbox refuses to
+// send to production; one marked EnvironmentProduction skips the test credentials check.
+func WithEnvironment(env Environment) Option {
+	return func(c *Client) {
+		c.environment = env
+	}
+}
+
 // WithCallbackURL sets the callback URL
 func WithCallbackURL(url string) Option {
 	return func(c *Client) {
@@ -52,3 +70,79 @@ func WithHTTPClientInterface(httpClient APIRequester) Option {
 		c.httpClient = httpClient
 	}
 }
+
+// WithRetryPolicy retries failed API calls according to policy.
+// Payments and deposits are only retried when the request was never sent, unless
+// policy.Retryable says otherwise.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = &policy
+	}
+}
+
+// WithTransactionStore records every payment and deposit, and the status updates seen for
+// them, in store
+func WithTransactionStore(store TransactionStore) Option {
+	return func(c *Client) {
+		c.store = store
+	}
+}
+
+// WithRateLimit throttles API calls, including retries, with limiter. Share one limiter
+// between clients that use the same account.
+func WithRateLimit(limiter *RateLimiter) Option {
+	return func(c *Client) {
+		c.rateLimiter = limiter
+	}
+}
+
+// WithCircuitBreaker fails calls fast with ErrCircuitOpen while breaker is open.
+// Share one breaker between clients that talk to the same API.
+func WithCircuitBreaker(breaker *CircuitBreaker) Option {
+	return func(c *Client) {
+		c.breaker = breaker
+	}
+}
+
+// WithMiddleware wraps every API call with middlewares. The first middleware is the
+// outermost one. Calling WithMiddleware again appends to the list.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(c *Client) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
+
+// WithLogger logs every HTTP call to logger with passwords and phone numbers redacted.
+// Each attempt of a retried call is logged separately.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithMetrics records request counts, latencies, retries, circuit breaker activity and the
+// last known balance in recorder
+func WithMetrics(recorder MetricsRecorder) Option {
+	return func(c *Client) {
+		c.metrics = recorder
+	}
+}
+
+// WithTracer starts a span for every Client method and a child span for every HTTP attempt.
+// When links is not nil, the trace context of payments and deposits is remembered in it so a
+// CallbackHandler created with WithCallbackTracer can continue the same trace.
+func WithTracer(tracer Tracer, links *TraceLinks) Option {
+	return func(c *Client) {
+		c.tracer = tracer
+		c.traceLinks = links
+	}
+}
+
+// WithStrictDecoding makes calls fail with an UnexpectedFieldsError when a response has
+// fields the library does not know or fields of an unexpected JSON type. Use it in staging
+// to notice API changes; by default such fields are kept in the Extra of the response.
+func WithStrictDecoding() Option {
+	return func(c *Client) {
+		c.strictDecoding = true
+	}
+}
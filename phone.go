@@ -1,12 +1,120 @@
 package Intouchpay
 
 import (
-	"errors"
-	"fmt"
+	"strings"
 
 	"github.com/samueltuyizere/validate_rw_phone_numbers"
 )
 
+// Operator is the mobile network a phone number belongs to
+type Operator string
+
+// Operators
+const (
+	OperatorMTN        Operator = "mtn"
+	OperatorAirtelTigo Operator = "airtel"
+)
+
+// String returns the operator name
+func (o Operator) String() string {
+	return string(o)
+}
+
+// PhoneNumber is a valid Rwandan mobile number. The zero value is an empty number.
+type PhoneNumber struct {
+	local    string // 10 digits with the leading 0
+	operator Operator
+}
+
+// ParsePhoneNumber validates a Rwandan mobile number in local (0781234567), national
+// (781234567), API (250781234567) or international (+250 788-123-456, 00250...) format.
+// Spaces, dashes, dots and parentheses are ignored.
+func ParsePhoneNumber(phoneNumber string) (PhoneNumber, error) {
+	digits := validate_rw_phone_numbers.NormalizePhoneNumber(strings.TrimSpace(phoneNumber))
+	digits = strings.TrimPrefix(digits, "00250")
+	if len(digits) == 12 {
+		digits = strings.TrimPrefix(digits, "250")
+	}
+	if len(digits) == 9 {
+		digits = "0" + digits
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return PhoneNumber{}, newValidationError("mobilePhone", "invalid phone number format")
+		}
+	}
+
+	switch {
+	case validate_rw_phone_numbers.ValidateMtn(digits):
+		return PhoneNumber{local: digits, operator: OperatorMTN}, nil
+	case validate_rw_phone_numbers.ValidateAirtelTigo(digits):
+		return PhoneNumber{local: digits, operator: OperatorAirtelTigo}, nil
+	default:
+		return PhoneNumber{}, newValidationError("mobilePhone", "invalid phone number format")
+	}
+}
+
+// IsZero reports whether p is the empty number
+func (p PhoneNumber) IsZero() bool {
+	return p.local == ""
+}
+
+// Operator returns the network the number belongs to
+func (p PhoneNumber) Operator() Operator {
+	return p.operator
+}
+
+// Local returns the number in local format, such as 0781234567
+func (p PhoneNumber) Local() string {
+	return p.local
+}
+
+// API returns the number in the format the IntouchPay API expects, such as 250781234567
+func (p PhoneNumber) API() string {
+	if p.IsZero() {
+		return ""
+	}
+	return "25" + p.local
+}
+
+// E164 returns the number in international format, such as +250781234567
+func (p PhoneNumber) E164() string {
+	if p.IsZero() {
+		return ""
+	}
+	return "+" + p.API()
+}
+
+// Masked returns the API format with all but the last three digits hidden, for logs
+func (p PhoneNumber) Masked() string {
+	return maskDigits(p.API())
+}
+
+// String returns the number in international format
+func (p PhoneNumber) String() string {
+	return p.E164()
+}
+
+// MarshalText encodes the number in the API format
+func (p PhoneNumber) MarshalText() ([]byte, error) {
+	return []byte(p.API()), nil
+}
+
+// UnmarshalText parses a number in any format accepted by ParsePhoneNumber. An empty value
+// gives the empty number.
+func (p *PhoneNumber) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*p = PhoneNumber{}
+		return nil
+	}
+	parsed, err := ParsePhoneNumber(string(text))
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
 // PhoneValidator validates and sanitizes Rwandan phone numbers
 type PhoneValidator struct{}
 
@@ -18,55 +126,26 @@ func NewPhoneValidator() *PhoneValidator {
 // SanitizePhoneNumber validates and formats a Rwandan phone number
 // It returns the number with the "250" country code prefix
 func (p *PhoneValidator) SanitizePhoneNumber(phoneNumber string) (string, error) {
-	// Remove any existing country code prefix to validate the base number
-	cleanedNumber := phoneNumber
-	if len(phoneNumber) > 3 && phoneNumber[:3] == "250" {
-		cleanedNumber = phoneNumber[3:] // Strip prefix to validate
-	}
-
-	// The validation library expects local format with leading "0"
-	// If the number doesn't start with "0", add it for validation
-	validationNumber := cleanedNumber
-	if len(cleanedNumber) > 0 && cleanedNumber[0] != '0' {
-		validationNumber = "0" + cleanedNumber
-	}
-
-	// Validate the number without country code
-	isValidMtn := validate_rw_phone_numbers.ValidateMtn(validationNumber)
-	isValidAirtelTigo := validate_rw_phone_numbers.ValidateAirtelTigo(validationNumber)
-	if !isValidMtn && !isValidAirtelTigo {
-		return "", newValidationError("mobilePhone", "invalid phone number format")
-	}
-
-	// Return with country code prefix
-	newNumber := fmt.Sprintf("25%s", validationNumber)
-	return newNumber, nil
+	return SanitizePhoneNumber(phoneNumber)
 }
 
 // SanitizePhoneNumber is a package-level function for backward compatibility
-// It validates and formats a Rwandan phone number with the "250" country code prefix
+// It validates and formats a Rwandan phone number with the "250" country code prefix.
+// Use ParsePhoneNumber to also learn the operator.
 func SanitizePhoneNumber(phoneNumber string) (string, error) {
-	// Remove any existing country code prefix to validate the base number
-	cleanedNumber := phoneNumber
-	if len(phoneNumber) > 3 && phoneNumber[:3] == "250" {
-		cleanedNumber = phoneNumber[3:] // Strip prefix to validate
-	}
-
-	// The validation library expects local format with leading "0"
-	// If the number doesn't start with "0", add it for validation
-	validationNumber := cleanedNumber
-	if len(cleanedNumber) > 0 && cleanedNumber[0] != '0' {
-		validationNumber = "0" + cleanedNumber
+	phone, err := ParsePhoneNumber(phoneNumber)
+	if err != nil {
+		return "", err
 	}
+	return phone.API(), nil
+}
 
-	// Validate the number without country code
-	isValidMtn := validate_rw_phone_numbers.ValidateMtn(validationNumber)
-	isValidAirtelTigo := validate_rw_phone_numbers.ValidateAirtelTigo(validationNumber)
-	if !isValidMtn && !isValidAirtelTigo {
-		return "", errors.New("invalid phone number")
+// phoneOperator returns the operator of a phone number in any accepted format, or an empty
+// string when it is invalid
+func phoneOperator(phoneNumber string) string {
+	phone, err := ParsePhoneNumber(phoneNumber)
+	if err != nil {
+		return ""
 	}
-
-	// Return with country code prefix
-	newNumber := fmt.Sprintf("25%s", validationNumber)
-	return newNumber, nil
+	return phone.Operator().String()
 }
@@ -1,6 +1,7 @@
 package Intouchpay_test
 
 import (
+	"encoding/json"
 	"testing"
 
 	Intouchpay "github.com/samueltuyizere/go-intouchpay"
@@ -80,3 +81,65 @@ func TestSanitizePhoneNumberInvalidWith250Prefix(t *testing.T) {
 	assert.Error(t, err, "invalid number with 250 prefix should be rejected")
 	assert.Contains(t, err.Error(), "invalid phone number")
 }
+
+// TestParsePhoneNumberFormats tests that every accepted input format gives the same number
+func TestParsePhoneNumberFormats(t *testing.T) {
+	inputs := []string{
+		"0788123456",
+		"788123456",
+		"250788123456",
+		"+250788123456",
+		"+250 788 123 456",
+		"+250-788-123-456",
+		"(+250) 788.123.456",
+		"00250788123456",
+	}
+	for _, input := range inputs {
+		phone, err := Intouchpay.ParsePhoneNumber(input)
+		if assert.NoError(t, err, input) {
+			assert.Equal(t, "250788123456", phone.API(), input)
+		}
+	}
+}
+
+// TestPhoneNumberFormatting tests the operator and output formats of a parsed number
+func TestPhoneNumberFormatting(t *testing.T) {
+	phone, err := Intouchpay.ParsePhoneNumber("+250 731 234 567")
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.OperatorAirtelTigo, phone.Operator())
+	assert.Equal(t, "+250731234567", phone.E164())
+	assert.Equal(t, "0731234567", phone.Local())
+	assert.Equal(t, "250731234567", phone.API())
+	assert.Equal(t, "*********567", phone.Masked())
+
+	phone, err = Intouchpay.ParsePhoneNumber("0791234567")
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.OperatorMTN, phone.Operator())
+
+	var zero Intouchpay.PhoneNumber
+	assert.True(t, zero.IsZero())
+	assert.Empty(t, zero.E164())
+}
+
+// TestParsePhoneNumberInvalid tests that malformed numbers are rejected with a validation error
+func TestParsePhoneNumberInvalid(t *testing.T) {
+	for _, input := range []string{"", "07812345678", "0761234567", "078123456a", "+1 788 123 456", "2500788123456"} {
+		_, err := Intouchpay.ParsePhoneNumber(input)
+		assert.True(t, Intouchpay.IsValidationError(err), input)
+	}
+}
+
+// TestPhoneNumberJSON tests that a phone number is encoded in the API format
+func TestPhoneNumberJSON(t *testing.T) {
+	var decoded struct {
+		Phone Intouchpay.PhoneNumber `json:"phone"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"phone":"+250 788 123 456"}`), &decoded))
+	assert.Equal(t, Intouchpay.OperatorMTN, decoded.Phone.Operator())
+
+	data, err := json.Marshal(decoded)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"phone":"250788123456"}`, string(data))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"phone":"12345"}`), &decoded))
+}
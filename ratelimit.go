@@ -0,0 +1,133 @@
+package Intouchpay
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Rate is a token bucket budget
+type Rate struct {
+	Limit float64 // Requests per second; zero or less means unlimited
+	Burst int     // Requests that may be sent at once, 1 if zero
+}
+
+// RateLimiter throttles API calls with one token bucket per endpoint. A RateLimiter may be
+// shared by several clients that use the same account so they draw from the same budgets.
+type RateLimiter struct {
+	mu          sync.Mutex
+	rate        Rate
+	perEndpoint map[string]Rate
+	buckets     map[string]*tokenBucket
+}
+
+// NewRateLimiter creates a RateLimiter. Endpoints listed in perEndpoint, keyed by endpoint
+// constant such as RequestDepositEndpoint, get their own rate; all others use rate.
+// Every endpoint has a separate bucket either way.
+func NewRateLimiter(rate Rate, perEndpoint map[string]Rate) *RateLimiter {
+	limits := make(map[string]Rate, len(perEndpoint))
+	for endpoint, r := range perEndpoint {
+		limits[endpoint] = r
+	}
+	return &RateLimiter{
+		rate:        rate,
+		perEndpoint: limits,
+		buckets:     make(map[string]*tokenBucket),
+	}
+}
+
+// RateLimitError is returned when ctx ends while a call waits for its rate limit.
+// The request was not sent.
+type RateLimitError struct {
+	Endpoint string
+	Err      error // The context error
+}
+
+// Error implements the error interface
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit wait for %s: %v", e.Endpoint, e.Err)
+}
+
+// Unwrap returns the context error
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+// Wait blocks until a call to endpoint is allowed or ctx is done
+func (l *RateLimiter) Wait(ctx context.Context, endpoint string) error {
+	for {
+		l.mu.Lock()
+		delay := l.bucket(endpoint).take(time.Now())
+		l.mu.Unlock()
+		if delay <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return &RateLimitError{Endpoint: endpoint, Err: ctx.Err()}
+		case <-timer.C:
+		}
+	}
+}
+
+// bucket returns the bucket for endpoint, creating it on first use. The caller must hold l.mu.
+func (l *RateLimiter) bucket(endpoint string) *tokenBucket {
+	if b, ok := l.buckets[endpoint]; ok {
+		return b
+	}
+	rate, ok := l.perEndpoint[endpoint]
+	if !ok {
+		rate = l.rate
+	}
+	if rate.Burst <= 0 {
+		rate.Burst = 1
+	}
+	b := &tokenBucket{rate: rate, tokens: float64(rate.Burst)}
+	l.buckets[endpoint] = b
+	return b
+}
+
+// tokenBucket holds the tokens available for one endpoint
+type tokenBucket struct {
+	rate   Rate
+	tokens float64
+	last   time.Time
+}
+
+// take removes a token if one is available and returns zero, or returns how long to wait
+// until one will be
+func (b *tokenBucket) take(now time.Time) time.Duration {
+	if b.rate.Limit <= 0 {
+		return 0
+	}
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate.Limit
+		if b.tokens > float64(b.rate.Burst) {
+			b.tokens = float64(b.rate.Burst)
+		}
+	}
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate.Limit * float64(time.Second))
+}
+
+// rateLimitRequester waits for the rate limiter before every call
+type rateLimitRequester struct {
+	next    Requester
+	limiter *RateLimiter
+}
+
+// DoInto sends the request once the rate limit allows it, giving up when ctx is done
+func (r *rateLimitRequester) DoInto(ctx context.Context, endpoint string, body, out interface{}) error {
+	if err := r.limiter.Wait(ctx, endpoint); err != nil {
+		return err
+	}
+	return r.next.DoInto(ctx, endpoint, body, out)
+}
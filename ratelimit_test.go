@@ -0,0 +1,54 @@
+package Intouchpay_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRateLimiterSharedBudget tests that clients sharing a limiter draw from one budget per endpoint
+func TestRateLimiterSharedBudget(t *testing.T) {
+	limiter := Intouchpay.NewRateLimiter(Intouchpay.Rate{}, map[string]Intouchpay.Rate{
+		Intouchpay.GetBalanceEndpoint: {Limit: 20, Burst: 1},
+	})
+	balance := &map[string]interface{}{"success": true, "balance": 100}
+	first := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, &MockHTTPClient{Response: balance}, Intouchpay.WithRateLimit(limiter))
+	second := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, &MockHTTPClient{Response: balance}, Intouchpay.WithRateLimit(limiter))
+
+	start := time.Now()
+	for _, client := range []*Intouchpay.Client{first, second, first} {
+		_, err := client.GetBalance()
+		assert.NoError(t, err)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+
+	// Other endpoints use the default rate, which is unlimited
+	start = time.Now()
+	for i := 0; i < 10; i++ {
+		_, err := first.GetTransactionStatus(&Intouchpay.GetTransactionStatusParams{RequestTransactionID: "TX1"})
+		assert.NoError(t, err)
+	}
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+}
+
+// TestRateLimiterHonoursContext tests that waiting for a token stops when ctx ends
+func TestRateLimiterHonoursContext(t *testing.T) {
+	limiter := Intouchpay.NewRateLimiter(Intouchpay.Rate{Limit: 0.1}, nil)
+	requester := &SequenceHTTPClient{Responses: []*map[string]interface{}{{"success": true, "responsecode": "2001"}}}
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, requester, Intouchpay.WithRateLimit(limiter))
+	params := &Intouchpay.RequestDepositParams{Amount: Intouchpay.RWF(100), MobilePhone: "0781234567", RequestTransactionID: "D1"}
+
+	_, err := client.RequestDepositContext(context.Background(), params)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = client.RequestDepositContext(ctx, params)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, Intouchpay.IsRequestNotSent(err))
+	assert.Equal(t, 1, requester.Calls)
+}
@@ -0,0 +1,181 @@
+package Intouchpay
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Mismatch describes how a stored transaction disagreed with IntouchPay
+type Mismatch string
+
+// Mismatch kinds
+const (
+	MismatchNone           Mismatch = ""
+	MismatchMissedCallback Mismatch = "missed_callback" // Final at IntouchPay but still pending or unknown locally
+	MismatchConflict       Mismatch = "conflict"        // Final locally with a different final state at IntouchPay
+	MismatchNotFound       Mismatch = "not_found"       // Stored locally but unknown to IntouchPay, left unchanged
+)
+
+// ReconcileOptions configures a Reconciler
+type ReconcileOptions struct {
+	// Query selects the records to check. When Query.States is empty, pending and unknown
+	// records are checked; add StateFailed to also catch failures that later succeeded.
+	Query    TransactionQuery
+	Interval time.Duration // Minimum delay between status queries, 500ms if zero
+	MinAge   time.Duration // Skip records updated more recently than this
+	// OnMismatch is called for every mismatch after the store has been corrected, so that
+	// orders can be marked paid or refunded.
+	OnMismatch func(ctx context.Context, result ReconcileResult)
+}
+
+// ReconcileResult is the outcome of checking one stored transaction
+type ReconcileResult struct {
+	RequestTransactionID string           `json:"requesttransactionid"`
+	Kind                 TransactionKind  `json:"kind"`
+	LocalState           TransactionState `json:"localstate"`            // State before the check
+	RemoteState          TransactionState `json:"remotestate,omitempty"` // State reported by IntouchPay
+	ResponseCode         ResponseCode     `json:"responsecode,omitempty"`
+	Mismatch             Mismatch         `json:"mismatch,omitempty"`
+	Corrected            bool             `json:"corrected"` // The stored record was updated
+	Error                string           `json:"error,omitempty"`
+}
+
+// ReconcileReport summarizes a reconciliation run
+type ReconcileReport struct {
+	StartedAt  time.Time         `json:"startedat"`
+	FinishedAt time.Time         `json:"finishedat"`
+	Checked    int               `json:"checked"`
+	Mismatches int               `json:"mismatches"`
+	Corrected  int               `json:"corrected"`
+	Errors     int               `json:"errors"`
+	Results    []ReconcileResult `json:"results"`
+}
+
+// Reconciler compares the transactions in a client's TransactionStore with their status at
+// IntouchPay and corrects the store
+type Reconciler struct {
+	client *Client
+	opts   ReconcileOptions
+}
+
+// NewReconciler creates a Reconciler for a client configured with WithTransactionStore
+func NewReconciler(client *Client, opts *ReconcileOptions) (*Reconciler, error) {
+	if client.store == nil {
+		return nil, newValidationError("store", "client has no transaction store")
+	}
+	o := ReconcileOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Interval <= 0 {
+		o.Interval = 500 * time.Millisecond
+	}
+	if len(o.Query.States) == 0 {
+		o.Query.States = []TransactionState{StatePending, StateUnknown}
+	}
+	return &Reconciler{client: client, opts: o}, nil
+}
+
+// Run checks every selected record once, oldest first. Status queries are spaced at least
+// Interval apart. Failed queries are recorded in the report and the run continues, except for
+// authentication failures, which stop it. When ctx ends the partial report is returned with ctx.Err().
+func (r *Reconciler) Run(ctx context.Context) (*ReconcileReport, error) {
+	report := &ReconcileReport{StartedAt: time.Now().UTC()}
+	records, err := r.client.store.Find(ctx, r.opts.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-r.opts.MinAge)
+	var lastQuery time.Time
+	for _, record := range records {
+		if r.opts.MinAge > 0 && record.UpdatedAt.After(cutoff) {
+			continue
+		}
+		if !lastQuery.IsZero() {
+			timer := time.NewTimer(time.Until(lastQuery.Add(r.opts.Interval)))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				report.FinishedAt = time.Now().UTC()
+				return report, ctx.Err()
+			case <-timer.C:
+			}
+		}
+		lastQuery = time.Now()
+
+		result, stop := r.check(ctx, record)
+		report.Checked++
+		report.Results = append(report.Results, result)
+		switch {
+		case result.Error != "":
+			report.Errors++
+		case result.Mismatch != MismatchNone:
+			report.Mismatches++
+		}
+		if result.Corrected {
+			report.Corrected++
+		}
+		if result.Mismatch != MismatchNone && r.opts.OnMismatch != nil {
+			r.opts.OnMismatch(ctx, result)
+		}
+		if ctx.Err() != nil {
+			report.FinishedAt = time.Now().UTC()
+			return report, ctx.Err()
+		}
+		if stop != nil {
+			report.FinishedAt = time.Now().UTC()
+			return report, stop
+		}
+	}
+
+	report.FinishedAt = time.Now().UTC()
+	return report, nil
+}
+
+// check queries the status of one record. GetTransactionStatusContext updates the store, so the
+// record is read again afterwards to see whether it changed. A non-nil error stops the run.
+func (r *Reconciler) check(ctx context.Context, record *TransactionRecord) (ReconcileResult, error) {
+	result := ReconcileResult{
+		RequestTransactionID: record.RequestTransactionID,
+		Kind:                 record.Kind,
+		LocalState:           record.State,
+	}
+	resp, err := r.client.GetTransactionStatusContext(ctx, &GetTransactionStatusParams{
+		RequestTransactionID: record.RequestTransactionID,
+		TransactionID:        record.TransactionID,
+	})
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	code := resp.Code()
+	result.ResponseCode = code
+	if code.Class() == ClassAuthFailure {
+		result.Error = code.Description()
+		return result, fmt.Errorf("reconciliation stopped: status query returned %s (%s)", code, code.Description())
+	}
+	remote, ok := stateForCode(code)
+	switch {
+	case code == CodeTransactionNotFound:
+		result.Mismatch = MismatchNotFound
+	case !ok:
+		result.Error = fmt.Sprintf("unexpected response code %s", code)
+		return result, nil
+	case remote.IsTerminal() && !record.State.IsTerminal():
+		result.Mismatch = MismatchMissedCallback
+	case remote.IsTerminal() && remote != record.State:
+		result.Mismatch = MismatchConflict
+	}
+	result.RemoteState = remote
+
+	updated, err := r.client.store.Get(ctx, record.RequestTransactionID)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	result.Corrected = updated.State != record.State
+	return result, nil
+}
@@ -0,0 +1,89 @@
+package Intouchpay_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+	"github.com/samueltuyizere/go-intouchpay/intouchpaytest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReconcilerCorrectsStore tests detection of missed callbacks, conflicts and lost requests
+func TestReconcilerCorrectsStore(t *testing.T) {
+	ctx := context.Background()
+	server := intouchpaytest.NewServer(intouchpaytest.WithBalance(Intouchpay.RWF(1000)))
+	defer server.Close()
+	store := Intouchpay.NewMemoryStore()
+	client := server.NewClient(Intouchpay.WithTransactionStore(store))
+	down := false
+	server.OnRequest(Intouchpay.RequestDepositEndpoint, func(*intouchpaytest.Request) *intouchpaytest.Outcome {
+		if down {
+			return &intouchpaytest.Outcome{HTTPStatus: http.StatusBadGateway}
+		}
+		return nil
+	})
+
+	// P1 is paid but its callback never arrives; P2 is still waiting for the customer
+	for _, id := range []string{"P1", "P2"} {
+		_, err := client.RequestPayment(&Intouchpay.RequestPaymentParams{Amount: Intouchpay.RWF(100), MobilePhone: "0781234567", RequestTransactionID: id})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, server.CompletePayment("P1", Intouchpay.CodeSuccessful))
+
+	// D1 went through but was recorded as failed; D2 never reached the server
+	_, err := client.RequestDeposit(&Intouchpay.RequestDepositParams{Amount: Intouchpay.RWF(100), MobilePhone: "0781234567", RequestTransactionID: "D1"})
+	assert.NoError(t, err)
+	record, err := store.Get(ctx, "D1")
+	assert.NoError(t, err)
+	record.State = Intouchpay.StateFailed
+	assert.NoError(t, store.Save(ctx, record))
+	down = true
+	_, err = client.RequestDeposit(&Intouchpay.RequestDepositParams{Amount: Intouchpay.RWF(100), MobilePhone: "0781234567", RequestTransactionID: "D2"})
+	assert.Error(t, err)
+
+	var events []Intouchpay.ReconcileResult
+	reconciler, err := Intouchpay.NewReconciler(client, &Intouchpay.ReconcileOptions{
+		Query:    Intouchpay.TransactionQuery{States: []Intouchpay.TransactionState{Intouchpay.StatePending, Intouchpay.StateUnknown, Intouchpay.StateFailed}},
+		Interval: time.Millisecond,
+		OnMismatch: func(_ context.Context, result Intouchpay.ReconcileResult) {
+			events = append(events, result)
+		},
+	})
+	assert.NoError(t, err)
+	report, err := reconciler.Run(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, report.Checked)
+	assert.Equal(t, 3, report.Mismatches)
+	assert.Equal(t, 2, report.Corrected)
+	byID := make(map[string]Intouchpay.ReconcileResult)
+	for _, result := range report.Results {
+		byID[result.RequestTransactionID] = result
+	}
+	assert.Equal(t, Intouchpay.MismatchMissedCallback, byID["P1"].Mismatch)
+	assert.Equal(t, Intouchpay.MismatchNone, byID["P2"].Mismatch)
+	assert.False(t, byID["P2"].Corrected)
+	assert.Equal(t, Intouchpay.MismatchConflict, byID["D1"].Mismatch)
+	assert.Equal(t, Intouchpay.StateSucceeded, byID["D1"].RemoteState)
+	assert.Equal(t, Intouchpay.MismatchNotFound, byID["D2"].Mismatch)
+	assert.False(t, byID["D2"].Corrected, "a lost request stays unknown")
+	assert.Len(t, events, 3)
+
+	for id, state := range map[string]Intouchpay.TransactionState{"P1": Intouchpay.StateSucceeded, "P2": Intouchpay.StatePending, "D1": Intouchpay.StateSucceeded, "D2": Intouchpay.StateUnknown} {
+		record, err := store.Get(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, state, record.State, id)
+	}
+}
+
+// TestReconcilerRequiresStore tests that a client without a store is refused
+func TestReconcilerRequiresStore(t *testing.T) {
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, &MockHTTPClient{})
+
+	_, err := Intouchpay.NewReconciler(client, nil)
+
+	assert.True(t, Intouchpay.IsValidationError(err))
+}
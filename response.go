@@ -0,0 +1,153 @@
+package Intouchpay
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ResponseMeta is embedded in every response type. It keeps the raw JSON body and the fields
+// the library does not know, so fields added by IntouchPay are not lost.
+type ResponseMeta struct {
+	// Extra holds the fields that match no struct field, and fields whose value could not be
+	// decoded into theirs, by their name in the body
+	Extra map[string]json.RawMessage `json:"-"`
+
+	raw      json.RawMessage
+	mistyped []string
+}
+
+// Raw returns the JSON body the response was decoded from. A response that went through an
+// APIRequester set with WithHTTPClientInterface, a middleware that changed the response map
+// or one that called Do rather than DoContext was decoded from that map encoded again, and
+// Raw returns the encoded map.
+func (m *ResponseMeta) Raw() json.RawMessage {
+	return m.raw
+}
+
+// unexpectedFields returns an UnexpectedFieldsError when the body had unknown or mistyped
+// fields, for strict decoding
+func (m *ResponseMeta) unexpectedFields(typeName string) error {
+	var unknown []string
+	for name := range m.Extra {
+		if !containsString(m.mistyped, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) == 0 && len(m.mistyped) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	mistyped := append([]string(nil), m.mistyped...)
+	sort.Strings(mistyped)
+	return &UnexpectedFieldsError{Type: typeName, Unknown: unknown, Mistyped: mistyped}
+}
+
+// strictChecker is implemented by the response types through ResponseMeta
+type strictChecker interface {
+	unexpectedFields(typeName string) error
+}
+
+// decodeResponse decodes data into target, a pointer to a response struct without an
+// UnmarshalJSON method, and fills meta. A field whose value its type rejects, whether for its
+// JSON type or its content (such as a balance of "N/A"), is left zero and kept in Extra
+// instead of failing the whole response. Only a body that is not a JSON object is an error.
+func decodeResponse(data []byte, target interface{}, meta *ResponseMeta) error {
+	meta.raw = append(json.RawMessage(nil), data...)
+	meta.Extra, meta.mistyped = nil, nil
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	v := reflect.ValueOf(target).Elem()
+	known := jsonFields(v.Type())
+
+	// The whole body is decoded at once when it can be; otherwise field by field to find
+	// every field that fails, not just the first
+	whole := json.Unmarshal(data, target) == nil
+	for name, value := range fields {
+		index, ok := known[strings.ToLower(name)]
+		if !ok {
+			meta.addExtra(name, value)
+			continue
+		}
+		if whole {
+			continue
+		}
+		field := v.FieldByIndex(index)
+		if err := json.Unmarshal(value, field.Addr().Interface()); err != nil {
+			field.SetZero()
+			meta.addExtra(name, value)
+			meta.mistyped = append(meta.mistyped, name)
+		}
+	}
+	return nil
+}
+
+// addExtra records a field that could not be mapped
+func (m *ResponseMeta) addExtra(name string, value json.RawMessage) {
+	if m.Extra == nil {
+		m.Extra = make(map[string]json.RawMessage)
+	}
+	m.Extra[name] = value
+}
+
+// fieldCache maps struct types to their JSON field indexes
+var fieldCache sync.Map
+
+// jsonFields returns the index of every field of struct type t that encoding/json decodes,
+// keyed by lower-cased JSON name since encoding/json matches names without case
+func jsonFields(t reflect.Type) map[string][]int {
+	if cached, ok := fieldCache.Load(t); ok {
+		if fields, ok := cached.(map[string][]int); ok {
+			return fields
+		}
+	}
+	fields := make(map[string][]int)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[strings.ToLower(name)] = f.Index
+	}
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// strictRequester fails calls whose response has unknown or mistyped fields
+type strictRequester struct {
+	next Requester
+}
+
+// DoInto sends the request and checks the decoded response
+func (r *strictRequester) DoInto(ctx context.Context, endpoint string, body, out interface{}) error {
+	if err := r.next.DoInto(ctx, endpoint, body, out); err != nil {
+		return err
+	}
+	if checker, ok := out.(strictChecker); ok {
+		return checker.unexpectedFields(reflect.TypeOf(out).Elem().Name())
+	}
+	return nil
+}
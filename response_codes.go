@@ -0,0 +1,306 @@
+package Intouchpay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ResponseCode is a response code returned by the IntouchPay API
+type ResponseCode string
+
+// Payment request response codes
+const (
+	CodePending                ResponseCode = "1000"
+	CodeSuccessful             ResponseCode = "01"
+	CodeMissingUsername        ResponseCode = "0002"
+	CodeMissingPassword        ResponseCode = "0003"
+	CodeMissingDate            ResponseCode = "0004"
+	CodeInvalidPassword        ResponseCode = "0005"
+	CodeNoIntouchPayAccount    ResponseCode = "0006"
+	CodeNoSuchUser             ResponseCode = "0007"
+	CodeAuthenticationFailed   ResponseCode = "0008"
+	CodeAmountNotPositive      ResponseCode = "2100"
+	CodeAmountBelowMinimum     ResponseCode = "2200"
+	CodeAmountAboveMaximum     ResponseCode = "2300"
+	CodeDuplicateTransactionID ResponseCode = "2400"
+	CodeRouteNotFound          ResponseCode = "2500"
+	CodeOperationNotAllowed    ResponseCode = "2600"
+	CodeTransactionFailed      ResponseCode = "2700"
+	CodeInsufficientFunds      ResponseCode = "1005"
+	CodeNumberNotRegistered    ResponseCode = "1002"
+	CodeGeneralFailure         ResponseCode = "1008"
+	CodeInvalidNumber          ResponseCode = "1200"
+	CodeNumberNotSupported     ResponseCode = "1100"
+	CodeUnknownException       ResponseCode = "1300"
+)
+
+// Deposit request response codes
+const (
+	CodeDepositSuccessful          ResponseCode = "2001"
+	CodeRequestError               ResponseCode = "1100" // Same value as CodeNumberNotSupported on payments
+	CodeServiceIDNotRecognized     ResponseCode = "1101"
+	CodeInvalidMobilePhone         ResponseCode = "1102"
+	CodePaymentAboveMaximum        ResponseCode = "1103"
+	CodePaymentBelowMinimum        ResponseCode = "1104"
+	CodeNetworkNotSupported        ResponseCode = "1105"
+	CodeOperationNotPermitted      ResponseCode = "1106"
+	CodeAccountNotConfigured       ResponseCode = "1107"
+	CodeInsufficientAccountBalance ResponseCode = "1108"
+	CodeDuplicateRemitID           ResponseCode = "1110"
+	CodeSubscriberNotIdentified    ResponseCode = "2102"
+	CodeNonExistentMobileAccount   ResponseCode = "2105"
+	CodeOwnMobileAccount           ResponseCode = "2106"
+	CodeInvalidAmountFormat        ResponseCode = "2107"
+	CodeInsufficientSourceFunds    ResponseCode = "2108"
+	CodeDailyLimitExceeded         ResponseCode = "2109"
+	CodeSourceAccountNotActive     ResponseCode = "2110"
+	CodeMobileAccountNotActive     ResponseCode = "2111"
+)
+
+// Transaction status response codes
+const (
+	CodeMissingTransactionID        ResponseCode = "3000"
+	CodeTransactionNotFound         ResponseCode = "3100"
+	CodeMissingRequestTransactionID ResponseCode = "3200"
+)
+
+// CodeClass classifies the outcome a ResponseCode represents
+type CodeClass int
+
+// Response code classes
+const (
+	ClassUnknown          CodeClass = iota // Code is not in the catalog
+	ClassPending                           // Transaction accepted, final status not known yet
+	ClassSuccess                           // Transaction completed
+	ClassPermanentFailure                  // Request is invalid and will fail again unchanged
+	ClassRetryableFailure                  // Failure that may clear up later, e.g. funds or limits
+	ClassAuthFailure                       // Credentials or account configuration are wrong
+)
+
+// String returns the name of the class
+func (c CodeClass) String() string {
+	switch c {
+	case ClassPending:
+		return "pending"
+	case ClassSuccess:
+		return "success"
+	case ClassPermanentFailure:
+		return "permanent_failure"
+	case ClassRetryableFailure:
+		return "retryable_failure"
+	case ClassAuthFailure:
+		return "auth_failure"
+	default:
+		return "unknown"
+	}
+}
+
+// IsFailure reports whether the class is one of the failure classes
+func (c CodeClass) IsFailure() bool {
+	return c == ClassPermanentFailure || c == ClassRetryableFailure || c == ClassAuthFailure
+}
+
+// codeInfo describes an entry in the response code catalog
+type codeInfo struct {
+	description string
+	class       CodeClass
+}
+
+// codeCatalog holds the documented response codes of API v1.2
+var codeCatalog = map[ResponseCode]codeInfo{
+	CodePending:                {"Pending", ClassPending},
+	CodeSuccessful:             {"Successful", ClassSuccess},
+	CodeMissingUsername:        {"Missing Username Information", ClassAuthFailure},
+	CodeMissingPassword:        {"Missing Password Information", ClassAuthFailure},
+	CodeMissingDate:            {"Missing Date Information", ClassAuthFailure},
+	CodeInvalidPassword:        {"Invalid Password", ClassAuthFailure},
+	CodeNoIntouchPayAccount:    {"User Does not have an intouchPay Account", ClassAuthFailure},
+	CodeNoSuchUser:             {"No such user", ClassAuthFailure},
+	CodeAuthenticationFailed:   {"Failed to Authenticate", ClassAuthFailure},
+	CodeAmountNotPositive:      {"Amount should be greater than 0", ClassPermanentFailure},
+	CodeAmountBelowMinimum:     {"Amount below minimum", ClassPermanentFailure},
+	CodeAmountAboveMaximum:     {"Amount above maximum", ClassPermanentFailure},
+	CodeDuplicateTransactionID: {"Duplicate Transaction ID", ClassPermanentFailure},
+	CodeRouteNotFound:          {"Route Not Found", ClassPermanentFailure},
+	CodeOperationNotAllowed:    {"Operation Not Allowed", ClassPermanentFailure},
+	CodeTransactionFailed:      {"Failed to Complete Transaction", ClassRetryableFailure},
+	CodeInsufficientFunds:      {"Failed Due to Insufficient Funds", ClassRetryableFailure},
+	CodeNumberNotRegistered:    {"Mobile number not registered on mobile money", ClassPermanentFailure},
+	CodeGeneralFailure:         {"General Failure", ClassRetryableFailure},
+	CodeInvalidNumber:          {"Invalid Number", ClassPermanentFailure},
+	CodeNumberNotSupported:     {"Number not supported on this Mobile money network / Error in Request", ClassPermanentFailure},
+	CodeUnknownException:       {"Failed to Complete Transaction, Unknown Exception", ClassRetryableFailure},
+
+	CodeDepositSuccessful:          {"Request Successful", ClassSuccess},
+	CodeServiceIDNotRecognized:     {"Service ID not Recognized", ClassPermanentFailure},
+	CodeInvalidMobilePhone:         {"Invalid Mobile Phone Number", ClassPermanentFailure},
+	CodePaymentAboveMaximum:        {"Payment Above Allowed Maximum", ClassPermanentFailure},
+	CodePaymentBelowMinimum:        {"Payment Below Allowed Minimum", ClassPermanentFailure},
+	CodeNetworkNotSupported:        {"Network Not Supported", ClassPermanentFailure},
+	CodeOperationNotPermitted:      {"Operation Not Permitted", ClassPermanentFailure},
+	CodeAccountNotConfigured:       {"Payment Account Not Configured", ClassAuthFailure},
+	CodeInsufficientAccountBalance: {"Insufficient Account Balance", ClassRetryableFailure},
+	CodeDuplicateRemitID:           {"Duplicate Remit ID", ClassPermanentFailure},
+	CodeSubscriberNotIdentified:    {"Subscriber Could not be Identified", ClassPermanentFailure},
+	CodeNonExistentMobileAccount:   {"Non Existent Mobile Account", ClassPermanentFailure},
+	CodeOwnMobileAccount:           {"Own Mobile Account Provided", ClassPermanentFailure},
+	CodeInvalidAmountFormat:        {"Invalid Amount Format", ClassPermanentFailure},
+	CodeInsufficientSourceFunds:    {"Insufficient Funds on Source Account", ClassRetryableFailure},
+	CodeDailyLimitExceeded:         {"Daily Limit Exceeded", ClassRetryableFailure},
+	CodeSourceAccountNotActive:     {"Source Account Not Active", ClassPermanentFailure},
+	CodeMobileAccountNotActive:     {"Mobile Account Not Active", ClassPermanentFailure},
+
+	CodeMissingTransactionID:        {"Missing Transaction ID Information", ClassPermanentFailure},
+	CodeTransactionNotFound:         {"Transaction Doesn't Exist", ClassPermanentFailure},
+	CodeMissingRequestTransactionID: {"Missing Request Transaction ID Information", ClassPermanentFailure},
+}
+
+// codesByNumber maps the numeric value of every catalog code to its canonical form,
+// so that 1 and "1" resolve to "01" and 2 resolves to "0002"
+var codesByNumber = func() map[int]ResponseCode {
+	index := make(map[int]ResponseCode, len(codeCatalog))
+	for code := range codeCatalog {
+		n, err := strconv.Atoi(string(code))
+		if err == nil {
+			index[n] = code
+		}
+	}
+	return index
+}()
+
+// ParseResponseCode converts a raw response code into its canonical catalog form.
+// Codes that are not in the catalog are returned unchanged apart from surrounding whitespace.
+func ParseResponseCode(s string) ResponseCode {
+	code := ResponseCode(strings.TrimSpace(s))
+	if _, ok := codeCatalog[code]; ok {
+		return code
+	}
+	if n, err := strconv.Atoi(string(code)); err == nil {
+		if canonical, ok := codesByNumber[n]; ok {
+			return canonical
+		}
+	}
+	return code
+}
+
+// ResponseCodeFromInt converts a numeric response code into its canonical catalog form
+func ResponseCodeFromInt(n int) ResponseCode {
+	if canonical, ok := codesByNumber[n]; ok {
+		return canonical
+	}
+	return ResponseCode(strconv.Itoa(n))
+}
+
+// canonical returns the catalog form of c, so that codes set by hand compare equal to the
+// constants
+func (c ResponseCode) canonical() ResponseCode {
+	if c == "" {
+		return ""
+	}
+	return ParseResponseCode(string(c))
+}
+
+// UnmarshalJSON accepts the code as a string or a number, as different endpoints send it,
+// and stores its catalog form: "01", "1" and 1 all decode to CodeSuccessful. null and ""
+// leave an empty code.
+func (c *ResponseCode) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		*c = ""
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*c = ParseResponseCode(s)
+		return nil
+	}
+	n, err := strconv.Atoi(string(data))
+	if err != nil {
+		// Numbers like 1000.0 are still whole codes
+		f, ferr := strconv.ParseFloat(string(data), 64)
+		if ferr != nil || f != float64(int(f)) {
+			return NewMarshalError("responsecode", fmt.Errorf("invalid response code %s", data))
+		}
+		n = int(f)
+	}
+	*c = ResponseCodeFromInt(n)
+	return nil
+}
+
+// responseCodeOf converts a decoded JSON responsecode value, a string or a number, to a
+// ResponseCode. Other values give an empty code.
+func responseCodeOf(v interface{}) ResponseCode {
+	switch v := v.(type) {
+	case string:
+		return ParseResponseCode(v)
+	case float64:
+		return ResponseCodeFromInt(int(v))
+	case int:
+		return ResponseCodeFromInt(v)
+	default:
+		return ""
+	}
+}
+
+// responseSummary returns the response code and success flag of a decoded response: one of
+// the response types, a map, or any type with a Code method. hasSuccess is false when the
+// response carries no success flag.
+func responseSummary(out interface{}) (code ResponseCode, success, hasSuccess bool) {
+	switch r := out.(type) {
+	case *RequestPaymentResponse:
+		return r.Code(), r.Success, true
+	case *RequestDepositResponse:
+		return r.Code(), r.Success, true
+	case *BalanceResponse:
+		return r.Code(), r.Success, true
+	case *GetTransactionStatusResponse:
+		return r.Code(), r.Success, true
+	case *FailedRequestResponse:
+		return r.Code(), r.Success, true
+	case **map[string]interface{}:
+		if r == nil || *r == nil {
+			return "", false, false
+		}
+		return responseSummary(*r)
+	case *map[string]interface{}:
+		if r == nil {
+			return "", false, false
+		}
+		success, hasSuccess = (*r)["success"].(bool)
+		return responseCodeOf((*r)["responsecode"]), success, hasSuccess
+	case interface{ Code() ResponseCode }:
+		return r.Code(), false, false
+	default:
+		return "", false, false
+	}
+}
+
+// String returns the code as sent by the API
+func (c ResponseCode) String() string {
+	return string(c)
+}
+
+// IsKnown reports whether the code is in the catalog
+func (c ResponseCode) IsKnown() bool {
+	_, ok := codeCatalog[c]
+	return ok
+}
+
+// Description returns the documented meaning of the code
+func (c ResponseCode) Description() string {
+	if info, ok := codeCatalog[c]; ok {
+		return info.description
+	}
+	return "Unknown response code"
+}
+
+// Class returns the outcome classification of the code
+func (c ResponseCode) Class() CodeClass {
+	return codeCatalog[c].class
+}
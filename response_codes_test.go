@@ -0,0 +1,136 @@
+package Intouchpay_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestResponseCodeClass tests the classification of catalog codes
+func TestResponseCodeClass(t *testing.T) {
+	tests := []struct {
+		code  Intouchpay.ResponseCode
+		class Intouchpay.CodeClass
+	}{
+		{Intouchpay.CodePending, Intouchpay.ClassPending},
+		{Intouchpay.CodeSuccessful, Intouchpay.ClassSuccess},
+		{Intouchpay.CodeDepositSuccessful, Intouchpay.ClassSuccess},
+		{Intouchpay.CodeDuplicateTransactionID, Intouchpay.ClassPermanentFailure},
+		{Intouchpay.CodeInsufficientFunds, Intouchpay.ClassRetryableFailure},
+		{Intouchpay.CodeInsufficientAccountBalance, Intouchpay.ClassRetryableFailure},
+		{Intouchpay.CodeDailyLimitExceeded, Intouchpay.ClassRetryableFailure},
+		{Intouchpay.CodeInvalidPassword, Intouchpay.ClassAuthFailure},
+		{Intouchpay.ResponseCode("9999"), Intouchpay.ClassUnknown},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.class, tt.code.Class(), "code %s", tt.code)
+	}
+}
+
+// TestResponseCodeDescription tests descriptions for known and unknown codes
+func TestResponseCodeDescription(t *testing.T) {
+	assert.Equal(t, "Duplicate Transaction ID", Intouchpay.CodeDuplicateTransactionID.Description())
+	assert.Equal(t, "Insufficient Account Balance", Intouchpay.CodeInsufficientAccountBalance.Description())
+	assert.Equal(t, "Unknown response code", Intouchpay.ResponseCode("9999").Description())
+	assert.True(t, Intouchpay.CodePending.IsKnown())
+	assert.False(t, Intouchpay.ResponseCode("9999").IsKnown())
+}
+
+// TestParseResponseCode tests normalization of raw codes
+func TestParseResponseCode(t *testing.T) {
+	assert.Equal(t, Intouchpay.CodeSuccessful, Intouchpay.ParseResponseCode("01"))
+	assert.Equal(t, Intouchpay.CodeSuccessful, Intouchpay.ParseResponseCode("1"))
+	assert.Equal(t, Intouchpay.CodeMissingUsername, Intouchpay.ParseResponseCode("2"))
+	assert.Equal(t, Intouchpay.CodePending, Intouchpay.ParseResponseCode(" 1000 "))
+	assert.Equal(t, Intouchpay.ResponseCode("abc"), Intouchpay.ParseResponseCode("abc"))
+	assert.Equal(t, Intouchpay.CodeSuccessful, Intouchpay.ResponseCodeFromInt(1))
+	assert.Equal(t, Intouchpay.ResponseCode("4242"), Intouchpay.ResponseCodeFromInt(4242))
+}
+
+// TestCodeClassString tests class names
+func TestCodeClassString(t *testing.T) {
+	assert.Equal(t, "pending", Intouchpay.ClassPending.String())
+	assert.Equal(t, "retryable_failure", Intouchpay.ClassRetryableFailure.String())
+	assert.Equal(t, "unknown", Intouchpay.ClassUnknown.String())
+	assert.True(t, Intouchpay.ClassAuthFailure.IsFailure())
+	assert.False(t, Intouchpay.ClassSuccess.IsFailure())
+}
+
+// TestResponseCodeAccessors tests the Code accessors on response types
+func TestResponseCodeAccessors(t *testing.T) {
+	payment := &Intouchpay.RequestPaymentResponse{ResponseCode: "1000"}
+	deposit := &Intouchpay.RequestDepositResponse{ResponseCode: "1108"}
+	status := &Intouchpay.GetTransactionStatusResponse{ResponseCode: "1"}
+	balance := &Intouchpay.BalanceResponse{}
+	event := &Intouchpay.CallbackEvent{ResponseCode: "01"}
+
+	assert.Equal(t, Intouchpay.CodePending, payment.Code())
+	assert.Equal(t, Intouchpay.CodeInsufficientAccountBalance, deposit.Code())
+	assert.Equal(t, Intouchpay.CodeSuccessful, status.Code())
+	assert.Equal(t, Intouchpay.ResponseCode(""), balance.Code())
+	assert.Equal(t, Intouchpay.ClassSuccess, event.Code().Class())
+}
+
+// TestResponseCodeUnmarshal tests that string, number and zero-padded codes decode to the catalog form
+func TestResponseCodeUnmarshal(t *testing.T) {
+	cases := map[string]Intouchpay.ResponseCode{
+		`"01"`:     Intouchpay.CodeSuccessful,
+		`"1"`:      Intouchpay.CodeSuccessful,
+		`1`:        Intouchpay.CodeSuccessful,
+		`"0005"`:   Intouchpay.CodeInvalidPassword,
+		`5`:        Intouchpay.CodeInvalidPassword,
+		`1000.0`:   Intouchpay.CodePending,
+		`" 2001 "`: Intouchpay.CodeDepositSuccessful,
+		`"9999"`:   Intouchpay.ResponseCode("9999"),
+		`null`:     "",
+		`""`:       "",
+	}
+	for input, want := range cases {
+		var code Intouchpay.ResponseCode
+		if assert.NoError(t, json.Unmarshal([]byte(input), &code), input) {
+			assert.Equal(t, want, code, input)
+		}
+	}
+
+	for _, input := range []string{`true`, `1.5`, `{}`} {
+		var code Intouchpay.ResponseCode
+		assert.Error(t, json.Unmarshal([]byte(input), &code), input)
+	}
+}
+
+// TestAPIPayloads decodes payloads in the shapes the API documents for each endpoint, with
+// codes sent both as strings and as numbers
+func TestAPIPayloads(t *testing.T) {
+	read := func(name string) []byte {
+		data, err := os.ReadFile(filepath.Join("testdata", "responses", name))
+		assert.NoError(t, err)
+		return data
+	}
+	decode := func(name string, out interface {
+		Code() Intouchpay.ResponseCode
+	}) Intouchpay.ResponseCode {
+		assert.NoError(t, json.Unmarshal(read(name), out), name)
+		return out.Code()
+	}
+
+	assert.Equal(t, Intouchpay.CodePending, decode("requestpayment_pending.json", &Intouchpay.RequestPaymentResponse{}))
+	assert.Equal(t, Intouchpay.CodePending, decode("requestpayment_numeric.json", &Intouchpay.RequestPaymentResponse{}))
+	assert.Equal(t, Intouchpay.CodeInvalidPassword, decode("requestpayment_auth_failure.json", &Intouchpay.RequestPaymentResponse{}))
+	assert.Equal(t, Intouchpay.CodeDepositSuccessful, decode("requestdeposit_success.json", &Intouchpay.RequestDepositResponse{}))
+	assert.Equal(t, Intouchpay.CodeInsufficientAccountBalance, decode("requestdeposit_insufficient_numeric.json", &Intouchpay.RequestDepositResponse{}))
+	assert.Equal(t, Intouchpay.ResponseCode(""), decode("getbalance_success.json", &Intouchpay.BalanceResponse{}))
+	assert.Equal(t, Intouchpay.CodeAuthenticationFailed, decode("getbalance_failure_string.json", &Intouchpay.BalanceResponse{}))
+	assert.Equal(t, Intouchpay.CodeSuccessful, decode("gettransactionstatus_success_numeric.json", &Intouchpay.GetTransactionStatusResponse{}))
+	assert.Equal(t, Intouchpay.CodeSuccessful, decode("gettransactionstatus_success_padded.json", &Intouchpay.GetTransactionStatusResponse{}))
+	assert.Equal(t, Intouchpay.CodeTransactionNotFound, decode("gettransactionstatus_not_found.json", &Intouchpay.GetTransactionStatusResponse{}))
+
+	event, err := Intouchpay.DecodeCallback(bytes.NewReader(read("callback_success.json")))
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.CodeSuccessful, event.Code())
+}
@@ -0,0 +1,106 @@
+package Intouchpay_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestResponseKeepsUnknownFields tests that fields the library does not know are kept with
+// the raw body
+func TestResponseKeepsUnknownFields(t *testing.T) {
+	body := `{"success":true,"balance":1500,"currency":"RWF","limits":{"daily":500000}}`
+	var resp Intouchpay.BalanceResponse
+	assert.NoError(t, json.Unmarshal([]byte(body), &resp))
+
+	assert.True(t, resp.Success)
+	assert.Equal(t, Intouchpay.RWF(1500), resp.Balance)
+	assert.JSONEq(t, body, string(resp.Raw()))
+	assert.Len(t, resp.Extra, 2)
+	assert.Equal(t, json.RawMessage(`"RWF"`), resp.Extra["currency"])
+	assert.JSONEq(t, `{"daily":500000}`, string(resp.Extra["limits"]))
+
+	// Field names match without case, as in encoding/json
+	resp = Intouchpay.BalanceResponse{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"Success":true}`), &resp))
+	assert.True(t, resp.Success)
+	assert.Empty(t, resp.Extra)
+}
+
+// TestResponseToleratesMistypedFields tests that a field its type rejects does not fail the
+// whole response
+func TestResponseToleratesMistypedFields(t *testing.T) {
+	body := `{"success":"yes","responsecode":"01","status":"Pending","message":["a","b"]}`
+	var resp Intouchpay.GetTransactionStatusResponse
+	assert.NoError(t, json.Unmarshal([]byte(body), &resp))
+
+	assert.False(t, resp.Success)
+	assert.Equal(t, Intouchpay.CodeSuccessful, resp.Code())
+	assert.Equal(t, "Pending", resp.Status)
+	assert.Empty(t, resp.Message)
+	assert.Equal(t, json.RawMessage(`"yes"`), resp.Extra["success"])
+	assert.Equal(t, json.RawMessage(`["a","b"]`), resp.Extra["message"])
+
+	// Values rejected by the Money and ResponseCode decoders are kept the same way
+	var balance Intouchpay.BalanceResponse
+	assert.NoError(t, json.Unmarshal([]byte(`{"success":true,"balance":"N/A","responsecode":true}`), &balance))
+	assert.True(t, balance.Success)
+	assert.True(t, balance.Balance.IsZero())
+	assert.Empty(t, balance.Code())
+	assert.Equal(t, json.RawMessage(`"N/A"`), balance.Extra["balance"])
+	assert.Equal(t, json.RawMessage(`true`), balance.Extra["responsecode"])
+
+	// A body that is not an object is still an error
+	assert.Error(t, json.Unmarshal([]byte(`[1,2]`), &Intouchpay.BalanceResponse{}))
+}
+
+// TestStrictDecoding tests that strict clients fail on unknown and mistyped fields
+func TestStrictDecoding(t *testing.T) {
+	newClient := func(body string, opts ...Intouchpay.Option) *Intouchpay.Client {
+		transport := Intouchpay.NewHTTPClient(&http.Client{Transport: stubTransport{body: body}}, "http://intouchpay.test")
+		return Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, transport, opts...)
+	}
+	params := &Intouchpay.GetTransactionStatusParams{RequestTransactionID: "TX1"}
+	drifted := `{"success":"true","responsecode":"01","message":"ok","channel":"USSD","fee":25}`
+
+	resp, err := newClient(drifted).GetTransactionStatus(params)
+	assert.NoError(t, err)
+	assert.Len(t, resp.Extra, 3)
+
+	_, err = newClient(drifted, Intouchpay.WithStrictDecoding()).GetTransactionStatus(params)
+	assert.True(t, Intouchpay.IsUnexpectedFieldsError(err))
+	var fieldsErr *Intouchpay.UnexpectedFieldsError
+	if assert.ErrorAs(t, err, &fieldsErr) {
+		assert.Equal(t, "GetTransactionStatusResponse", fieldsErr.Type)
+		assert.Equal(t, []string{"channel", "fee"}, fieldsErr.Unknown)
+		assert.Equal(t, []string{"success"}, fieldsErr.Mistyped)
+	}
+	assert.EqualError(t, err, "unexpected fields in GetTransactionStatusResponse: unknown channel, fee; mistyped success")
+
+	// Fields rejected by the Money and ResponseCode decoders are mistyped too
+	balanceBody := `{"success":true,"balance":"N/A","responsecode":true}`
+	balance, err := newClient(balanceBody).GetBalance()
+	if assert.NoError(t, err) {
+		assert.True(t, balance.Success)
+		assert.Len(t, balance.Extra, 2)
+	}
+	_, err = newClient(balanceBody, Intouchpay.WithStrictDecoding()).GetBalance()
+	if assert.ErrorAs(t, err, &fieldsErr) {
+		assert.Empty(t, fieldsErr.Unknown)
+		assert.Equal(t, []string{"balance", "responsecode"}, fieldsErr.Mistyped)
+	}
+
+	// The documented payloads decode cleanly in strict mode
+	for _, name := range []string{"gettransactionstatus_success_numeric.json", "gettransactionstatus_not_found.json"} {
+		data, err := os.ReadFile(filepath.Join("testdata", "responses", name))
+		assert.NoError(t, err)
+		_, err = newClient(string(data), Intouchpay.WithStrictDecoding()).GetTransactionStatusContext(context.Background(), params)
+		assert.NoError(t, err, name)
+	}
+}
@@ -0,0 +1,168 @@
+package Intouchpay
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how failed API calls are retried
+type RetryPolicy struct {
+	MaxAttempts    int           // Total attempts including the first one; values below 2 disable retries
+	InitialBackoff time.Duration // Delay before the first retry
+	MaxBackoff     time.Duration // Upper bound for a single delay
+	Multiplier     float64       // Growth factor between delays, 2 if zero
+	Jitter         float64       // Fraction of each delay that is randomized, between 0 and 1
+	MaxRetryAfter  time.Duration // Longest Retry-After delay honoured; longer ones end the retries
+	// Retryable decides whether a failed attempt against endpoint is retried.
+	// DefaultRetryable is used when nil.
+	Retryable func(endpoint string, err error) bool
+}
+
+// DefaultRetryPolicy returns a policy with three attempts and exponential backoff starting at 500ms
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		MaxRetryAfter:  30 * time.Second,
+	}
+}
+
+// DefaultRetryable is the default retryability rule.
+// Balance and status queries are retried on network errors, 429 and 5xx responses.
+// ErrCircuitOpen is never retried.
+// Payments and deposits move money, so they are only retried when the request provably never
+// reached the server (see IsRequestNotSent).
+func DefaultRetryable(endpoint string, err error) bool {
+	if err == nil || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	if IsRequestNotSent(err) {
+		return true
+	}
+	if !isIdempotentEndpoint(endpoint) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError || apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// IsRequestNotSent reports whether err happened before any bytes of the request were sent,
+// such as a DNS lookup failure, a refused connection, an open circuit breaker or a cancelled
+// rate limit wait
+func IsRequestNotSent(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) {
+		return true
+	}
+	var limitErr *RateLimitError
+	if errors.As(err, &limitErr) {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isIdempotentEndpoint reports whether calling endpoint twice has no additional effect
+func isIdempotentEndpoint(endpoint string) bool {
+	return endpoint == GetBalanceEndpoint || endpoint == GetTransactionStatusEndpoint
+}
+
+// backoff returns the delay before retry number n, starting at 1
+func (p RetryPolicy) backoff(n int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier == 0 {
+		multiplier = 2
+	}
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(n-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		delay -= delay * p.Jitter * rand.Float64()
+	}
+	return time.Duration(delay)
+}
+
+// retryAfter returns the delay requested by a Retry-After header on err, if any
+func retryAfter(err error) (time.Duration, bool) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Header == nil {
+		return 0, false
+	}
+	value := apiErr.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, convErr := strconv.Atoi(value); convErr == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, parseErr := http.ParseTime(value); parseErr == nil {
+		return time.Until(at), true
+	}
+	return 0, false
+}
+
+// retryRequester retries failed calls according to a RetryPolicy
+type retryRequester struct {
+	next    Requester
+	policy  RetryPolicy
+	metrics MetricsRecorder // Optional
+	// refresh returns body with fresh credentials, so that retries are not refused for a
+	// stale timestamp. Optional.
+	refresh func(body interface{}) (interface{}, error)
+}
+
+// DoInto sends the request, retrying according to the policy until ctx is done
+func (r *retryRequester) DoInto(ctx context.Context, endpoint string, body, out interface{}) error {
+	retryable := r.policy.Retryable
+	if retryable == nil {
+		retryable = DefaultRetryable
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := r.next.DoInto(ctx, endpoint, body, out)
+		if err == nil || attempt >= r.policy.MaxAttempts || ctx.Err() != nil || !retryable(endpoint, err) {
+			return err
+		}
+
+		delay := r.policy.backoff(attempt)
+		if wait, ok := retryAfter(err); ok {
+			if r.policy.MaxRetryAfter > 0 && wait > r.policy.MaxRetryAfter {
+				return err
+			}
+			delay = max(delay, wait)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		if r.refresh != nil {
+			if body, err = r.refresh(body); err != nil {
+				return err
+			}
+		}
+		if r.metrics != nil {
+			r.metrics.IncRetry(endpoint)
+		}
+	}
+}
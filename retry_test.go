@@ -0,0 +1,196 @@
+package Intouchpay_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+	"github.com/stretchr/testify/assert"
+)
+
+// dialFailTransport fails every request with a connection-refused dial error
+type dialFailTransport struct {
+	calls int32
+}
+
+func (d *dialFailTransport) RoundTrip(_ *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&d.calls, 1)
+	return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+}
+
+// fastRetryPolicy returns a retry policy with negligible delays
+func fastRetryPolicy() Intouchpay.RetryPolicy {
+	policy := Intouchpay.DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	policy.MaxBackoff = 5 * time.Millisecond
+	return policy
+}
+
+// newFlakyServer returns a server that answers with failStatus for the first failures requests
+func newFlakyServer(t *testing.T, failures int32, failStatus int, header http.Header) (*httptest.Server, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		for key, values := range header {
+			w.Header()[key] = values
+		}
+		if n <= failures {
+			w.WriteHeader(failStatus)
+			_, _ = w.Write([]byte("<html>Bad Gateway</html>"))
+			return
+		}
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "balance": 500}); err != nil {
+			t.Error(err)
+		}
+	}))
+	return server, &calls
+}
+
+// TestRetryPolicyRetriesBalanceOn5xx tests that idempotent queries are retried on server errors
+func TestRetryPolicyRetriesBalanceOn5xx(t *testing.T) {
+	server, calls := newFlakyServer(t, 2, http.StatusBadGateway, nil)
+	defer server.Close()
+
+	client := Intouchpay.NewClientWithHTTPClient(
+		&MockAuthenticator{},
+		Intouchpay.NewHTTPClient(&http.Client{Timeout: 5 * time.Second}, server.URL),
+		Intouchpay.WithRetryPolicy(fastRetryPolicy()),
+	)
+
+	resp, err := client.GetBalance()
+
+	assert.NoError(t, err)
+	assert.True(t, resp.Success)
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+}
+
+// TestRetryPolicyDoesNotRetryPaymentOn5xx tests that payments are not retried once sent
+func TestRetryPolicyDoesNotRetryPaymentOn5xx(t *testing.T) {
+	server, calls := newFlakyServer(t, 2, http.StatusServiceUnavailable, nil)
+	defer server.Close()
+
+	client := Intouchpay.NewClientWithHTTPClient(
+		&MockAuthenticator{},
+		Intouchpay.NewHTTPClient(&http.Client{Timeout: 5 * time.Second}, server.URL),
+		Intouchpay.WithRetryPolicy(fastRetryPolicy()),
+	)
+
+	_, err := client.RequestPayment(&Intouchpay.RequestPaymentParams{
+		Amount:               Intouchpay.RWF(1000),
+		MobilePhone:          "0781234567",
+		RequestTransactionID: "TX1",
+	})
+
+	assert.True(t, Intouchpay.IsAPIError(err))
+	assert.Equal(t, http.StatusServiceUnavailable, Intouchpay.HTTPStatus(err))
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+}
+
+// TestRetryPolicyRetriesDepositWhenNotSent tests that deposits are retried on dial errors
+func TestRetryPolicyRetriesDepositWhenNotSent(t *testing.T) {
+	transport := &dialFailTransport{}
+	client := Intouchpay.NewClientWithHTTPClient(
+		&MockAuthenticator{},
+		Intouchpay.NewHTTPClient(&http.Client{Transport: transport}, "http://intouchpay.invalid"),
+		Intouchpay.WithRetryPolicy(fastRetryPolicy()),
+	)
+
+	_, err := client.RequestDeposit(&Intouchpay.RequestDepositParams{
+		Amount:               Intouchpay.RWF(1000),
+		MobilePhone:          "0781234567",
+		RequestTransactionID: "TX2",
+	})
+
+	assert.Error(t, err)
+	assert.True(t, Intouchpay.IsRequestNotSent(err))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&transport.calls))
+}
+
+// stampingAuthenticator returns credentials with a new timestamp and password on every call
+type stampingAuthenticator struct {
+	calls int32
+}
+
+// Authenticate returns the next credentials
+func (a *stampingAuthenticator) Authenticate() Intouchpay.Credentials {
+	n := strconv.Itoa(int(atomic.AddInt32(&a.calls, 1)))
+	return Intouchpay.Credentials{Username: "shop", Timestamp: "2026032012000" + n, Password: "hash" + n}
+}
+
+// TestRetryPolicyRefreshesCredentials tests that each attempt is sent with fresh credentials
+func TestRetryPolicyRefreshesCredentials(t *testing.T) {
+	var mu sync.Mutex
+	var timestamps, passwords []string
+	var accounts []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		mu.Lock()
+		timestamps = append(timestamps, body["timestamp"].(string))
+		passwords = append(passwords, body["password"].(string))
+		accounts = append(accounts, body["accountno"])
+		failed := len(timestamps)%3 != 0
+		mu.Unlock()
+		if failed {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"success":true,"balance":500}`))
+	}))
+	defer server.Close()
+
+	client := Intouchpay.NewClientWithHTTPClient(
+		&stampingAuthenticator{},
+		Intouchpay.NewHTTPClient(&http.Client{Timeout: 5 * time.Second}, server.URL),
+		Intouchpay.WithRetryPolicy(fastRetryPolicy()),
+	)
+	_, err := client.GetBalance()
+	assert.NoError(t, err)
+	var out map[string]interface{}
+	assert.NoError(t, client.Call(context.Background(), Intouchpay.GetBalanceEndpoint, map[string]interface{}{"accountno": "OTHER"}, &out))
+
+	assert.Equal(t, []string{"20260320120001", "20260320120002", "20260320120003", "20260320120004", "20260320120005", "20260320120006"}, timestamps)
+	assert.Equal(t, []string{"hash1", "hash2", "hash3", "hash4", "hash5", "hash6"}, passwords)
+	assert.Equal(t, []interface{}{"OTHER", "OTHER", "OTHER"}, accounts[3:], "the account number of Call params is kept")
+}
+
+// TestRetryPolicyRetryAfterCap tests that Retry-After delays above the cap end the retries
+func TestRetryPolicyRetryAfterCap(t *testing.T) {
+	server, calls := newFlakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"120"}})
+	defer server.Close()
+
+	client := Intouchpay.NewClientWithHTTPClient(
+		&MockAuthenticator{},
+		Intouchpay.NewHTTPClient(&http.Client{Timeout: 5 * time.Second}, server.URL),
+		Intouchpay.WithRetryPolicy(fastRetryPolicy()),
+	)
+
+	_, err := client.GetBalance()
+
+	assert.Equal(t, http.StatusTooManyRequests, Intouchpay.HTTPStatus(err))
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+}
+
+// TestDefaultRetryable tests the default retryability rule per endpoint
+func TestDefaultRetryable(t *testing.T) {
+	serverErr := &Intouchpay.APIError{StatusCode: http.StatusInternalServerError}
+	badRequest := &Intouchpay.APIError{StatusCode: http.StatusBadRequest}
+	dialErr := &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+	readErr := &net.OpError{Op: "read", Err: errors.New("connection reset")}
+
+	assert.True(t, Intouchpay.DefaultRetryable(Intouchpay.GetTransactionStatusEndpoint, serverErr))
+	assert.True(t, Intouchpay.DefaultRetryable(Intouchpay.GetBalanceEndpoint, readErr))
+	assert.False(t, Intouchpay.DefaultRetryable(Intouchpay.GetBalanceEndpoint, badRequest))
+	assert.False(t, Intouchpay.DefaultRetryable(Intouchpay.RequestPaymentEndpoint, serverErr))
+	assert.False(t, Intouchpay.DefaultRetryable(Intouchpay.RequestDepositEndpoint, readErr))
+	assert.True(t, Intouchpay.DefaultRetryable(Intouchpay.RequestDepositEndpoint, dialErr))
+}
@@ -0,0 +1,189 @@
+package Intouchpay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrNoAccount is returned when no account in a Router can serve a call
+var ErrNoAccount = errors.New("no account can serve the request")
+
+// Strategy picks the account of a Router that serves a call moving amount
+type Strategy func(ctx context.Context, router *Router, amount Money) (string, error)
+
+// Router holds one Client per IntouchPay account and routes calls between them
+type Router struct {
+	mu      sync.RWMutex
+	names   []string // In the order the accounts were added
+	clients map[string]*Client
+	next    int // Round robin position
+}
+
+// NewRouter creates an empty Router
+func NewRouter() *Router {
+	return &Router{clients: make(map[string]*Client)}
+}
+
+// Add registers client under name. Strategies that walk the accounts use the order in which
+// they were added.
+func (r *Router) Add(name string, client *Client) error {
+	if name == "" {
+		return newValidationError("name", "account name is empty")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.clients[name]; exists {
+		return newValidationError("name", fmt.Sprintf("account %q already exists", name))
+	}
+	r.names = append(r.names, name)
+	r.clients[name] = client
+	return nil
+}
+
+// Client returns the client registered under name
+func (r *Router) Client(name string) (*Client, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	client, ok := r.clients[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown account %q", ErrNoAccount, name)
+	}
+	return client, nil
+}
+
+// Names returns the account names in the order they were added
+func (r *Router) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.names...)
+}
+
+// ByName always picks the named account
+func ByName(name string) Strategy {
+	return func(_ context.Context, router *Router, _ Money) (string, error) {
+		if _, err := router.Client(name); err != nil {
+			return "", err
+		}
+		return name, nil
+	}
+}
+
+// RoundRobin picks the accounts in turn
+func RoundRobin() Strategy {
+	return func(_ context.Context, router *Router, _ Money) (string, error) {
+		router.mu.Lock()
+		defer router.mu.Unlock()
+		if len(router.names) == 0 {
+			return "", ErrNoAccount
+		}
+		name := router.names[router.next%len(router.names)]
+		router.next++
+		return name, nil
+	}
+}
+
+// FirstWithBalance picks the first account whose balance covers the amount. Balances are
+// queried one account at a time; accounts whose balance query fails are skipped.
+func FirstWithBalance() Strategy {
+	return func(ctx context.Context, router *Router, amount Money) (string, error) {
+		for _, name := range router.Names() {
+			client, err := router.Client(name)
+			if err != nil {
+				continue
+			}
+			balance, err := client.GetBalanceContext(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return "", ctx.Err()
+				}
+				continue
+			}
+			if balance.Success && balance.Balance.Cmp(amount) >= 0 {
+				return name, nil
+			}
+		}
+		return "", fmt.Errorf("%w: no account has a balance of %s", ErrNoAccount, amount)
+	}
+}
+
+// Pick returns the account chosen by strategy for a call moving amount
+func (r *Router) Pick(ctx context.Context, strategy Strategy, amount Money) (string, *Client, error) {
+	name, err := strategy(ctx, r, amount)
+	if err != nil {
+		return "", nil, err
+	}
+	client, err := r.Client(name)
+	if err != nil {
+		return "", nil, err
+	}
+	return name, client, nil
+}
+
+// RequestPayment initiates a payment on the account chosen by strategy and returns the name
+// of that account with the response
+func (r *Router) RequestPayment(ctx context.Context, strategy Strategy, params *RequestPaymentParams) (string, *RequestPaymentResponse, error) {
+	name, client, err := r.Pick(ctx, strategy, params.Amount)
+	if err != nil {
+		return "", nil, err
+	}
+	resp, err := client.RequestPaymentContext(ctx, params)
+	return name, resp, err
+}
+
+// RequestDeposit initiates a deposit from the account chosen by strategy and returns the name
+// of that account with the response
+func (r *Router) RequestDeposit(ctx context.Context, strategy Strategy, params *RequestDepositParams) (string, *RequestDepositResponse, error) {
+	name, client, err := r.Pick(ctx, strategy, params.Amount)
+	if err != nil {
+		return "", nil, err
+	}
+	resp, err := client.RequestDepositContext(ctx, params)
+	return name, resp, err
+}
+
+// AggregateBalance is the combined balance of a Router's accounts
+type AggregateBalance struct {
+	Total    Money                       // Sum of the balances that were returned successfully
+	Accounts map[string]*BalanceResponse // Successful balance responses by account
+	Errors   map[string]error            // Failed balance queries by account
+}
+
+// GetBalance queries the balance of every account concurrently and adds them up. Accounts
+// whose query fails or is unsuccessful are listed in Errors and left out of Total.
+func (r *Router) GetBalance(ctx context.Context) *AggregateBalance {
+	names := r.Names()
+	aggregate := &AggregateBalance{
+		Accounts: make(map[string]*BalanceResponse, len(names)),
+		Errors:   make(map[string]error),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, name := range names {
+		client, err := r.Client(name)
+		if err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(name string, client *Client) {
+			defer wg.Done()
+			balance, err := client.GetBalanceContext(ctx)
+			if err == nil && !balance.Success {
+				err = fmt.Errorf("balance query failed with code %s", balance.Code())
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				aggregate.Errors[name] = err
+				return
+			}
+			aggregate.Accounts[name] = balance
+			aggregate.Total = aggregate.Total.Add(balance.Balance)
+		}(name, client)
+	}
+	wg.Wait()
+	return aggregate
+}
@@ -0,0 +1,78 @@
+package Intouchpay_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+	"github.com/samueltuyizere/go-intouchpay/intouchpaytest"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestRouter returns a router over two fake accounts with the given balances
+func newTestRouter(t *testing.T, retail, wholesale int64) (*Intouchpay.Router, *intouchpaytest.Server, *intouchpaytest.Server) {
+	retailServer := intouchpaytest.NewServer(intouchpaytest.WithBalance(Intouchpay.RWF(retail)))
+	wholesaleServer := intouchpaytest.NewServer(intouchpaytest.WithBalance(Intouchpay.RWF(wholesale)))
+	t.Cleanup(retailServer.Close)
+	t.Cleanup(wholesaleServer.Close)
+	router := Intouchpay.NewRouter()
+	assert.NoError(t, router.Add("retail", retailServer.NewClient()))
+	assert.NoError(t, router.Add("wholesale", wholesaleServer.NewClient()))
+	return router, retailServer, wholesaleServer
+}
+
+// TestRouterFirstWithBalance tests that deposits go to the first account that can cover them
+func TestRouterFirstWithBalance(t *testing.T) {
+	router, retail, wholesale := newTestRouter(t, 100, 5000)
+
+	name, resp, err := router.RequestDeposit(context.Background(), Intouchpay.FirstWithBalance(),
+		&Intouchpay.RequestDepositParams{Amount: Intouchpay.RWF(1000), MobilePhone: "0781234567", RequestTransactionID: "D1"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "wholesale", name)
+	assert.True(t, resp.Success)
+	assert.Equal(t, Intouchpay.RWF(100), retail.Balance())
+	assert.Equal(t, Intouchpay.RWF(4000), wholesale.Balance())
+
+	_, _, err = router.RequestDeposit(context.Background(), Intouchpay.FirstWithBalance(),
+		&Intouchpay.RequestDepositParams{Amount: Intouchpay.RWF(10000), MobilePhone: "0781234567", RequestTransactionID: "D2"})
+	assert.True(t, errors.Is(err, Intouchpay.ErrNoAccount))
+}
+
+// TestRouterByNameAndRoundRobin tests explicit and round robin account selection
+func TestRouterByNameAndRoundRobin(t *testing.T) {
+	router, _, _ := newTestRouter(t, 100, 100)
+	ctx := context.Background()
+
+	name, _, err := router.Pick(ctx, Intouchpay.ByName("retail"), Intouchpay.Money{})
+	assert.NoError(t, err)
+	assert.Equal(t, "retail", name)
+	_, _, err = router.Pick(ctx, Intouchpay.ByName("missing"), Intouchpay.Money{})
+	assert.True(t, errors.Is(err, Intouchpay.ErrNoAccount))
+
+	strategy := Intouchpay.RoundRobin()
+	var picked []string
+	for i := 0; i < 3; i++ {
+		name, _, err := router.Pick(ctx, strategy, Intouchpay.Money{})
+		assert.NoError(t, err)
+		picked = append(picked, name)
+	}
+	assert.Equal(t, []string{"retail", "wholesale", "retail"}, picked)
+
+	assert.True(t, Intouchpay.IsValidationError(router.Add("retail", Intouchpay.NewClientWithAuth(&MockAuthenticator{}))))
+}
+
+// TestRouterAggregateBalance tests adding up balances and reporting failed accounts
+func TestRouterAggregateBalance(t *testing.T) {
+	router, _, _ := newTestRouter(t, 1500, 2500)
+	broken := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, &MockHTTPClient{Error: errors.New("connection reset")})
+	assert.NoError(t, router.Add("broken", broken))
+
+	aggregate := router.GetBalance(context.Background())
+
+	assert.Equal(t, Intouchpay.RWF(4000), aggregate.Total)
+	assert.Len(t, aggregate.Accounts, 2)
+	assert.Equal(t, Intouchpay.RWF(1500), aggregate.Accounts["retail"].Balance)
+	assert.Error(t, aggregate.Errors["broken"])
+}
@@ -0,0 +1,383 @@
+package Intouchpay
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrTransactionNotFound is returned by TransactionStore.Get for unknown request transaction IDs
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// ErrTransactionExists is returned by TransactionStore.Create when a transaction that has not
+// failed is stored under the same request transaction ID
+var ErrTransactionExists = errors.New("transaction already exists")
+
+// TransactionKind identifies the operation that created a transaction
+type TransactionKind string
+
+// Transaction kinds
+const (
+	TransactionPayment TransactionKind = "payment"
+	TransactionDeposit TransactionKind = "deposit"
+)
+
+// TransactionState is the local view of a transaction's progress
+type TransactionState string
+
+// Transaction states
+const (
+	StateUnknown   TransactionState = "unknown" // Sent, but no answer was received
+	StatePending   TransactionState = "pending"
+	StateSucceeded TransactionState = "succeeded"
+	StateFailed    TransactionState = "failed"
+)
+
+// IsTerminal reports whether the state is final
+func (s TransactionState) IsTerminal() bool {
+	return s == StateSucceeded || s == StateFailed
+}
+
+// Sources of transaction updates
+const (
+	SourceRequest  = "request"
+	SourceStatus   = "status"
+	SourceCallback = "callback"
+)
+
+// TransactionUpdate records one change observed for a transaction
+type TransactionUpdate struct {
+	At           time.Time        `json:"at"`
+	Source       string           `json:"source"`
+	State        TransactionState `json:"state"`
+	ResponseCode ResponseCode     `json:"responsecode,omitempty"`
+	Status       string           `json:"status,omitempty"`
+	Error        string           `json:"error,omitempty"`
+}
+
+// TransactionRecord is the locally stored history of a payment or deposit
+type TransactionRecord struct {
+	Kind                 TransactionKind     `json:"kind"`
+	RequestTransactionID string              `json:"requesttransactionid"`
+	TransactionID        string              `json:"transactionid,omitempty"` // Assigned by IntouchPay
+	ReferenceID          string              `json:"referenceid,omitempty"`
+	MobilePhone          string              `json:"mobilephone"` // In the 250... API format
+	Amount               Money               `json:"amount"`
+	Reason               string              `json:"reason,omitempty"`
+	State                TransactionState    `json:"state"`
+	ResponseCode         ResponseCode        `json:"responsecode,omitempty"`
+	Status               string              `json:"status,omitempty"`
+	CreatedAt            time.Time           `json:"createdat"`
+	UpdatedAt            time.Time           `json:"updatedat"`
+	Updates              []TransactionUpdate `json:"updates,omitempty"`
+}
+
+// clone returns a deep copy of the record
+func (r *TransactionRecord) clone() *TransactionRecord {
+	c := *r
+	c.Updates = append([]TransactionUpdate(nil), r.Updates...)
+	return &c
+}
+
+// advance applies update unless it would move a terminal record back to a non-terminal state,
+// as a late status or callback can. It reports whether the update was applied.
+func (r *TransactionRecord) advance(update TransactionUpdate) bool {
+	if r.State.IsTerminal() && !update.State.IsTerminal() {
+		return false
+	}
+	r.apply(update)
+	return true
+}
+
+// apply appends update to the record history and updates the current state
+func (r *TransactionRecord) apply(update TransactionUpdate) {
+	r.Updates = append(r.Updates, update)
+	r.UpdatedAt = update.At
+	r.State = update.State
+	if update.ResponseCode != "" {
+		r.ResponseCode = update.ResponseCode
+	}
+	if update.Status != "" {
+		r.Status = update.Status
+	}
+}
+
+// TransactionQuery selects records in TransactionStore.Find. Empty fields match everything.
+type TransactionQuery struct {
+	RequestTransactionID string
+	TransactionID        string
+	MobilePhone          string // Any format accepted by ParsePhoneNumber
+	Kind                 TransactionKind
+	States               []TransactionState
+	From                 time.Time // Inclusive lower bound on CreatedAt
+	To                   time.Time // Exclusive upper bound on CreatedAt
+}
+
+// matches reports whether r satisfies the query
+func (q TransactionQuery) matches(r *TransactionRecord, phone string) bool {
+	if q.RequestTransactionID != "" && r.RequestTransactionID != q.RequestTransactionID {
+		return false
+	}
+	if q.TransactionID != "" && r.TransactionID != q.TransactionID {
+		return false
+	}
+	if phone != "" && r.MobilePhone != phone {
+		return false
+	}
+	if q.Kind != "" && r.Kind != q.Kind {
+		return false
+	}
+	if len(q.States) > 0 {
+		found := false
+		for _, state := range q.States {
+			if r.State == state {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !q.From.IsZero() && r.CreatedAt.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !r.CreatedAt.Before(q.To) {
+		return false
+	}
+	return true
+}
+
+// TransactionStore persists transaction records
+type TransactionStore interface {
+	// Save inserts the record or replaces the one with the same RequestTransactionID
+	Save(ctx context.Context, record *TransactionRecord) error
+	// Create inserts the record if no record with the same RequestTransactionID is stored, or
+	// replaces the stored one if it failed. Otherwise it returns ErrTransactionExists. The
+	// check and the write must be atomic, so that a transaction is only sent once.
+	Create(ctx context.Context, record *TransactionRecord) error
+	// Get returns the record for a request transaction ID or ErrTransactionNotFound
+	Get(ctx context.Context, requestTransactionID string) (*TransactionRecord, error)
+	// Find returns the records matching query, oldest first
+	Find(ctx context.Context, query TransactionQuery) ([]*TransactionRecord, error)
+}
+
+// MemoryStore is a TransactionStore that keeps records in memory
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[string]*TransactionRecord
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]*TransactionRecord)}
+}
+
+// Save inserts or replaces a record
+func (s *MemoryStore) Save(_ context.Context, record *TransactionRecord) error {
+	if record.RequestTransactionID == "" {
+		return newValidationError("requesttransactionid", "record has no request transaction ID")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[record.RequestTransactionID] = record.clone()
+	return nil
+}
+
+// Create inserts a record unless a transaction that has not failed is stored under its ID
+func (s *MemoryStore) Create(_ context.Context, record *TransactionRecord) error {
+	if record.RequestTransactionID == "" {
+		return newValidationError("requesttransactionid", "record has no request transaction ID")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkAbsent(record.RequestTransactionID); err != nil {
+		return err
+	}
+	s.records[record.RequestTransactionID] = record.clone()
+	return nil
+}
+
+// checkAbsent returns ErrTransactionExists when a transaction that has not failed is stored
+// under requestTransactionID. s.mu must be held.
+func (s *MemoryStore) checkAbsent(requestTransactionID string) error {
+	if existing, ok := s.records[requestTransactionID]; ok && existing.State != StateFailed {
+		return ErrTransactionExists
+	}
+	return nil
+}
+
+// Get returns a copy of the record for a request transaction ID
+func (s *MemoryStore) Get(_ context.Context, requestTransactionID string) (*TransactionRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.records[requestTransactionID]
+	if !ok {
+		return nil, ErrTransactionNotFound
+	}
+	return record.clone(), nil
+}
+
+// Find returns copies of the records matching query, oldest first
+func (s *MemoryStore) Find(_ context.Context, query TransactionQuery) ([]*TransactionRecord, error) {
+	phone := ""
+	if query.MobilePhone != "" {
+		var err error
+		if phone, err = SanitizePhoneNumber(query.MobilePhone); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var found []*TransactionRecord
+	for _, record := range s.records {
+		if query.matches(record, phone) {
+			found = append(found, record.clone())
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].CreatedAt.Equal(found[j].CreatedAt) {
+			return found[i].RequestTransactionID < found[j].RequestTransactionID
+		}
+		return found[i].CreatedAt.Before(found[j].CreatedAt)
+	})
+	return found, nil
+}
+
+// stateForCode maps a response code to a transaction state. Codes that describe the status
+// query itself rather than the transaction, such as 3100, report false.
+func stateForCode(code ResponseCode) (TransactionState, bool) {
+	switch code {
+	case CodeMissingTransactionID, CodeTransactionNotFound, CodeMissingRequestTransactionID:
+		return "", false
+	}
+	switch code.Class() {
+	case ClassPending:
+		return StatePending, true
+	case ClassSuccess:
+		return StateSucceeded, true
+	case ClassPermanentFailure, ClassRetryableFailure:
+		return StateFailed, true
+	default:
+		return "", false
+	}
+}
+
+// beginRecord stores the record of a payment or deposit before it is sent. A failure aborts
+// the request. Reusing the request transaction ID of a stored transaction is refused unless
+// that transaction failed; the store's Create makes the check atomic, so two concurrent
+// requests with the same ID cannot both be sent.
+func (c *Client) beginRecord(ctx context.Context, kind TransactionKind, requestTransactionID, mobilePhone, reason string, amount Money) (*TransactionRecord, error) {
+	if c.store == nil {
+		return nil, nil
+	}
+	now := time.Now().UTC()
+	record, err := c.store.Get(ctx, requestTransactionID)
+	switch {
+	case err == nil && record.State != StateFailed:
+		return nil, newValidationError("requesttransactionid", "a "+string(record.State)+" transaction with this ID already exists")
+	case err == nil:
+		record.Amount = amount
+		record.MobilePhone = mobilePhone
+		record.Reason = reason
+	case errors.Is(err, ErrTransactionNotFound):
+		record = &TransactionRecord{
+			Kind:                 kind,
+			RequestTransactionID: requestTransactionID,
+			MobilePhone:          mobilePhone,
+			Amount:               amount,
+			Reason:               reason,
+			CreatedAt:            now,
+		}
+	default:
+		return nil, err
+	}
+	record.apply(TransactionUpdate{At: now, Source: SourceRequest, State: StateUnknown})
+	if err := c.store.Create(ctx, record); err != nil {
+		if errors.Is(err, ErrTransactionExists) {
+			return nil, newValidationError("requesttransactionid", "a transaction with this ID is already being sent")
+		}
+		return nil, err
+	}
+	return record, nil
+}
+
+// finishRecord stores the outcome of a payment or deposit request. Failures are logged
+// because the money may already have moved. The record is saved even if ctx was cancelled.
+func (c *Client) finishRecord(ctx context.Context, record *TransactionRecord, transactionID, referenceID string, code ResponseCode, reqErr error) {
+	if record == nil {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	// A callback may have been applied while the request was in flight
+	if stored, err := c.store.Get(ctx, record.RequestTransactionID); err == nil {
+		record = stored
+	}
+	if transactionID != "" {
+		record.TransactionID = transactionID
+	}
+	if referenceID != "" {
+		record.ReferenceID = referenceID
+	}
+	update := TransactionUpdate{At: time.Now().UTC(), Source: SourceRequest, State: StateUnknown, ResponseCode: code}
+	switch {
+	case reqErr != nil && IsRequestNotSent(reqErr):
+		update.State = StateFailed
+		update.Error = reqErr.Error()
+	case reqErr != nil:
+		update.Error = reqErr.Error()
+	case code.Class() == ClassAuthFailure:
+		update.State = StateFailed
+	default:
+		if state, ok := stateForCode(code); ok {
+			update.State = state
+		}
+	}
+	record.advance(update)
+	if err := c.store.Save(ctx, record); err != nil {
+		c.warnf("failed to record transaction %s: %v", record.RequestTransactionID, err)
+	}
+}
+
+// recordUpdate applies an update from a status query or callback to a stored record. A
+// terminal record never goes back to pending, and 3100 (not found) leaves an unknown record
+// unknown: right after an ambiguous send, IntouchPay may simply not have registered the
+// transaction yet, so it is not safe to send again.
+func (c *Client) recordUpdate(ctx context.Context, requestTransactionID, transactionID, referenceID, source string, code ResponseCode, status string) error {
+	if c.store == nil || requestTransactionID == "" {
+		return nil
+	}
+	record, err := c.store.Get(ctx, requestTransactionID)
+	if err != nil {
+		return err
+	}
+	state, ok := stateForCode(code)
+	if !ok {
+		return nil
+	}
+	if !record.advance(TransactionUpdate{At: time.Now().UTC(), Source: source, State: state, ResponseCode: code, Status: status}) {
+		return nil
+	}
+	if record.TransactionID == "" {
+		record.TransactionID = transactionID
+	}
+	if referenceID != "" {
+		record.ReferenceID = referenceID
+	}
+	return c.store.Save(ctx, record)
+}
+
+// ApplyCallback records a callback event in the client's TransactionStore.
+// It does nothing when no store is configured and returns ErrTransactionNotFound for
+// transactions the store does not know.
+func (c *Client) ApplyCallback(ctx context.Context, event *CallbackEvent) error {
+	return c.recordUpdate(ctx, event.RequestTransactionID, event.TransactionID, event.ReferenceNo, SourceCallback, event.Code(), event.Status)
+}
+
+// TransactionStore returns the store configured with WithTransactionStore, or nil
+func (c *Client) TransactionStore() TransactionStore {
+	return c.store
+}
@@ -0,0 +1,251 @@
+package Intouchpay_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+	"github.com/samueltuyizere/go-intouchpay/intouchpaytest"
+	"github.com/stretchr/testify/assert"
+)
+
+// storeRecords returns three records created a day apart, starting at start
+func storeRecords(start time.Time) []*Intouchpay.TransactionRecord {
+	return []*Intouchpay.TransactionRecord{
+		{Kind: Intouchpay.TransactionPayment, RequestTransactionID: "R1", TransactionID: "T1", MobilePhone: "250781234567", Amount: Intouchpay.RWF(100), State: Intouchpay.StateSucceeded, CreatedAt: start},
+		{Kind: Intouchpay.TransactionDeposit, RequestTransactionID: "R2", MobilePhone: "250781234567", Amount: Intouchpay.RWF(200), State: Intouchpay.StateUnknown, CreatedAt: start.Add(24 * time.Hour)},
+		{Kind: Intouchpay.TransactionPayment, RequestTransactionID: "R3", TransactionID: "T3", MobilePhone: "250731234567", Amount: Intouchpay.RWF(300), State: Intouchpay.StatePending, CreatedAt: start.Add(48 * time.Hour)},
+	}
+}
+
+// TestMemoryStoreFind tests lookups by ID, external ID, phone, state and date range
+func TestMemoryStoreFind(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	store := Intouchpay.NewMemoryStore()
+	for _, record := range storeRecords(start) {
+		assert.NoError(t, store.Save(ctx, record))
+	}
+
+	record, err := store.Get(ctx, "R2")
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.RWF(200), record.Amount)
+	_, err = store.Get(ctx, "missing")
+	assert.ErrorIs(t, err, Intouchpay.ErrTransactionNotFound)
+
+	ids := func(query Intouchpay.TransactionQuery) []string {
+		records, err := store.Find(ctx, query)
+		assert.NoError(t, err)
+		var found []string
+		for _, r := range records {
+			found = append(found, r.RequestTransactionID)
+		}
+		return found
+	}
+	assert.Equal(t, []string{"R1", "R2", "R3"}, ids(Intouchpay.TransactionQuery{}))
+	assert.Equal(t, []string{"R3"}, ids(Intouchpay.TransactionQuery{TransactionID: "T3"}))
+	assert.Equal(t, []string{"R1", "R2"}, ids(Intouchpay.TransactionQuery{MobilePhone: "0781234567"}))
+	assert.Equal(t, []string{"R2", "R3"}, ids(Intouchpay.TransactionQuery{States: []Intouchpay.TransactionState{Intouchpay.StateUnknown, Intouchpay.StatePending}}))
+	assert.Equal(t, []string{"R2"}, ids(Intouchpay.TransactionQuery{From: start.Add(time.Hour), To: start.Add(48 * time.Hour)}))
+	assert.Equal(t, []string{"R1"}, ids(Intouchpay.TransactionQuery{Kind: Intouchpay.TransactionPayment, To: start.Add(time.Hour)}))
+}
+
+// TestFileStoreReload tests that a reopened file store keeps the latest version of each record
+func TestFileStoreReload(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "ledger.jsonl")
+	store, err := Intouchpay.OpenFileStore(path)
+	assert.NoError(t, err)
+	records := storeRecords(time.Now().UTC())
+	for _, record := range records {
+		assert.NoError(t, store.Save(ctx, record))
+	}
+	records[1].State = Intouchpay.StateSucceeded
+	assert.NoError(t, store.Save(ctx, records[1]))
+	assert.NoError(t, store.Close())
+
+	// Simulate a crash in the middle of a write
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	assert.NoError(t, err)
+	_, err = file.WriteString(`{"kind":"payment","requesttransactionid":"R4"`)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	reopened, err := Intouchpay.OpenFileStore(path)
+	assert.NoError(t, err)
+	record, err := reopened.Get(ctx, "R2")
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.StateSucceeded, record.State)
+	all, err := reopened.Find(ctx, Intouchpay.TransactionQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	// The truncated line is removed, so records saved afterwards can be read back
+	assert.NoError(t, reopened.Save(ctx, &Intouchpay.TransactionRecord{Kind: Intouchpay.TransactionPayment, RequestTransactionID: "R4", State: Intouchpay.StatePending}))
+	assert.NoError(t, reopened.Close())
+	reopened, err = Intouchpay.OpenFileStore(path)
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, reopened.Close())
+	}()
+	record, err = reopened.Get(ctx, "R4")
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.StatePending, record.State)
+	all, err = reopened.Find(ctx, Intouchpay.TransactionQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, all, 4)
+}
+
+// TestClientRecordsTransactions tests that payments, deposits and status updates are recorded
+func TestClientRecordsTransactions(t *testing.T) {
+	ctx := context.Background()
+	server := intouchpaytest.NewServer(intouchpaytest.WithBalance(Intouchpay.RWF(1000)))
+	defer server.Close()
+	store := Intouchpay.NewMemoryStore()
+	client := server.NewClient(Intouchpay.WithTransactionStore(store))
+
+	payment, err := client.RequestPayment(&Intouchpay.RequestPaymentParams{Amount: Intouchpay.RWF(500), MobilePhone: "0781234567", RequestTransactionID: "P1"})
+	assert.NoError(t, err)
+	record, err := store.Get(ctx, "P1")
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.TransactionPayment, record.Kind)
+	assert.Equal(t, Intouchpay.StatePending, record.State)
+	assert.Equal(t, payment.TransactionID, record.TransactionID)
+	assert.Equal(t, "250781234567", record.MobilePhone)
+
+	assert.NoError(t, server.CompletePayment("P1", Intouchpay.CodeSuccessful))
+	_, err = client.GetTransactionStatus(&Intouchpay.GetTransactionStatusParams{RequestTransactionID: "P1"})
+	assert.NoError(t, err)
+	record, err = store.Get(ctx, "P1")
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.StateSucceeded, record.State)
+	assert.Len(t, record.Updates, 3)
+	assert.Equal(t, Intouchpay.SourceStatus, record.Updates[2].Source)
+
+	_, err = client.RequestPayment(&Intouchpay.RequestPaymentParams{Amount: Intouchpay.RWF(500), MobilePhone: "0781234567", RequestTransactionID: "P1"})
+	assert.True(t, Intouchpay.IsValidationError(err))
+
+	deposit, err := client.RequestDeposit(&Intouchpay.RequestDepositParams{Amount: Intouchpay.RWF(300), MobilePhone: "0781234567", RequestTransactionID: "D1", Reason: "refund"})
+	assert.NoError(t, err)
+	record, err = store.Get(ctx, "D1")
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.StateSucceeded, record.State)
+	assert.Equal(t, deposit.ReferenceID, record.ReferenceID)
+	assert.Equal(t, "refund", record.Reason)
+	assert.Len(t, server.Transactions(), 2)
+}
+
+// TestClientRecordsUnknownOutcome tests that a request without an answer stays unknown, even
+// when a status query does not find it, and is never sent again
+func TestClientRecordsUnknownOutcome(t *testing.T) {
+	ctx := context.Background()
+	server := intouchpaytest.NewServer(intouchpaytest.WithBalance(Intouchpay.RWF(1000)))
+	defer server.Close()
+	store := Intouchpay.NewMemoryStore()
+	client := server.NewClient(Intouchpay.WithTransactionStore(store))
+	down := true
+	server.OnRequest(Intouchpay.RequestDepositEndpoint, func(*intouchpaytest.Request) *intouchpaytest.Outcome {
+		if down {
+			return &intouchpaytest.Outcome{HTTPStatus: http.StatusBadGateway}
+		}
+		return nil
+	})
+	params := &Intouchpay.RequestDepositParams{Amount: Intouchpay.RWF(100), MobilePhone: "0781234567", RequestTransactionID: "D1"}
+
+	_, err := client.RequestDeposit(params)
+	assert.Error(t, err)
+	record, err := store.Get(ctx, "D1")
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.StateUnknown, record.State)
+	assert.NotEmpty(t, record.Updates[1].Error)
+
+	down = false
+	_, err = client.RequestDeposit(params)
+	assert.True(t, Intouchpay.IsValidationError(err), "an unknown deposit must not be sent again")
+
+	_, err = client.GetTransactionStatus(&Intouchpay.GetTransactionStatusParams{RequestTransactionID: "D1"})
+	assert.NoError(t, err)
+	record, err = store.Get(ctx, "D1")
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.StateUnknown, record.State, "3100 right after an ambiguous send proves nothing")
+
+	_, err = client.RequestDeposit(params)
+	assert.True(t, Intouchpay.IsValidationError(err))
+	assert.Empty(t, server.Transactions())
+}
+
+// TestTerminalRecordsStayTerminal tests that late status answers and callbacks never move a
+// finished transaction back to pending
+func TestTerminalRecordsStayTerminal(t *testing.T) {
+	ctx := context.Background()
+	store := Intouchpay.NewMemoryStore()
+	assert.NoError(t, store.Save(ctx, &Intouchpay.TransactionRecord{RequestTransactionID: "P1", State: Intouchpay.StateSucceeded}))
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, &MockHTTPClient{}, Intouchpay.WithTransactionStore(store))
+
+	err := client.ApplyCallback(ctx, &Intouchpay.CallbackEvent{RequestTransactionID: "P1", ResponseCode: "1000", Status: "Pending"})
+	assert.NoError(t, err)
+	record, err := store.Get(ctx, "P1")
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.StateSucceeded, record.State)
+	assert.Empty(t, record.Updates)
+}
+
+// TestStoreCreate tests that Create refuses live transactions atomically and replaces failed ones
+func TestStoreCreate(t *testing.T) {
+	ctx := context.Background()
+	fileStore, err := Intouchpay.OpenFileStore(filepath.Join(t.TempDir(), "transactions.jsonl"))
+	assert.NoError(t, err)
+	defer fileStore.Close()
+
+	for _, store := range []Intouchpay.TransactionStore{Intouchpay.NewMemoryStore(), fileStore} {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		created := 0
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := store.Create(ctx, &Intouchpay.TransactionRecord{RequestTransactionID: "P1", State: Intouchpay.StateUnknown})
+				if err == nil {
+					mu.Lock()
+					created++
+					mu.Unlock()
+					return
+				}
+				assert.ErrorIs(t, err, Intouchpay.ErrTransactionExists)
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 1, created)
+
+		assert.NoError(t, store.Save(ctx, &Intouchpay.TransactionRecord{RequestTransactionID: "P1", State: Intouchpay.StateFailed}))
+		assert.NoError(t, store.Create(ctx, &Intouchpay.TransactionRecord{RequestTransactionID: "P1", State: Intouchpay.StateUnknown}))
+		record, err := store.Get(ctx, "P1")
+		assert.NoError(t, err)
+		assert.Equal(t, Intouchpay.StateUnknown, record.State)
+	}
+}
+
+// TestApplyCallback tests that callback events update stored payments
+func TestApplyCallback(t *testing.T) {
+	ctx := context.Background()
+	store := Intouchpay.NewMemoryStore()
+	assert.NoError(t, store.Save(ctx, &Intouchpay.TransactionRecord{RequestTransactionID: "P1", State: Intouchpay.StatePending}))
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, &MockHTTPClient{}, Intouchpay.WithTransactionStore(store))
+
+	err := client.ApplyCallback(ctx, &Intouchpay.CallbackEvent{RequestTransactionID: "P1", TransactionID: "T1", ResponseCode: "01", Status: "Successfull", ReferenceNo: "REF"})
+	assert.NoError(t, err)
+	record, err := store.Get(ctx, "P1")
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.StateSucceeded, record.State)
+	assert.Equal(t, "T1", record.TransactionID)
+	assert.Equal(t, "REF", record.ReferenceID)
+
+	err = client.ApplyCallback(ctx, &Intouchpay.CallbackEvent{RequestTransactionID: "other", ResponseCode: "01"})
+	assert.ErrorIs(t, err, Intouchpay.ErrTransactionNotFound)
+}
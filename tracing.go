@@ -0,0 +1,199 @@
+package Intouchpay
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Span attribute keys
+const (
+	AttrEndpoint             = "intouchpay.endpoint"
+	AttrAmount               = "intouchpay.amount"
+	AttrOperator             = "intouchpay.operator"
+	AttrResponseCode         = "intouchpay.response_code"
+	AttrRequestTransactionID = "intouchpay.request_transaction_id"
+	AttrHTTPStatus           = "http.response.status_code"
+)
+
+// Attribute is a key-value pair attached to a span
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Tracer starts spans. Implement it with an adapter around an OpenTelemetry tracer so the
+// core module does not depend on the OTel SDK:
+//
+//	func (t otelTracer) Start(ctx context.Context, name string, attrs ...Intouchpay.Attribute) (context.Context, Intouchpay.Span) {
+//		ctx, span := t.tracer.Start(ctx, name)
+//		s := otelSpan{span}
+//		s.SetAttributes(attrs...)
+//		return ctx, s
+//	}
+type Tracer interface {
+	// Start starts a span as a child of the span in ctx, if any, and returns a context carrying it
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span is a unit of work started by a Tracer
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// noopSpan is used when no tracer is configured
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute) {}
+func (noopSpan) RecordError(error)          {}
+func (noopSpan) End()                       {}
+
+// TraceLinks remembers the trace context of payments and deposits by request transaction ID,
+// so that the CallbackHandler can continue the same trace when the callback arrives.
+// Share one TraceLinks between the client and the callback handler.
+//
+// An entry is kept for every payment and deposit sent within the TTL, so memory grows with
+// the number of transactions sent in that time; pick a TTL that covers the callback delay
+// and little more.
+type TraceLinks struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	extract   func(ctx context.Context) context.Context
+	links     map[string]traceLink
+	nextPrune time.Time
+}
+
+// traceLink is the remembered context of one transaction
+type traceLink struct {
+	ctx     context.Context
+	expires time.Time
+}
+
+// NewTraceLinks creates a TraceLinks that forgets transactions after ttl, 24 hours if zero.
+// extract returns a new context holding only the span context of the context it is given,
+// so that request-scoped values of the caller are not kept alive. With OpenTelemetry:
+//
+//	func(ctx context.Context) context.Context {
+//		return trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+//	}
+//
+// Nothing is remembered when extract is nil.
+func NewTraceLinks(ttl time.Duration, extract func(ctx context.Context) context.Context) *TraceLinks {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	if extract == nil {
+		log.Printf("warning: TraceLinks created without an extract function; callbacks will not be linked to their payments")
+	}
+	return &TraceLinks{ttl: ttl, extract: extract, links: make(map[string]traceLink)}
+}
+
+// remember stores the span context of a transaction, as returned by the extract function
+func (l *TraceLinks) remember(requestTransactionID string, ctx context.Context) {
+	if l == nil || l.extract == nil || requestTransactionID == "" {
+		return
+	}
+	linked := l.extract(ctx)
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.After(l.nextPrune) {
+		for id, link := range l.links {
+			if now.After(link.expires) {
+				delete(l.links, id)
+			}
+		}
+		l.nextPrune = now.Add(l.ttl / 10)
+	}
+	l.links[requestTransactionID] = traceLink{ctx: linked, expires: now.Add(l.ttl)}
+}
+
+// forgetUnsent drops the context of a transaction when err shows its request never left.
+// Contexts are remembered just before sending so that an early callback finds them.
+func (l *TraceLinks) forgetUnsent(requestTransactionID string, err error) {
+	if l == nil || !(IsRequestNotSent(err) || errors.Is(err, ErrProductionGuard)) {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.links, requestTransactionID)
+}
+
+// lookup returns the context remembered for a transaction
+func (l *TraceLinks) lookup(requestTransactionID string) (context.Context, bool) {
+	if l == nil {
+		return nil, false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	link, ok := l.links[requestTransactionID]
+	if !ok || time.Now().After(link.expires) {
+		return nil, false
+	}
+	return link.ctx, true
+}
+
+// linkedContext takes its values, such as the current span, from one context and its
+// deadline and cancellation from another
+type linkedContext struct {
+	context.Context
+	values context.Context
+}
+
+// Value looks key up in the linked values first
+func (c linkedContext) Value(key interface{}) interface{} {
+	if v := c.values.Value(key); v != nil {
+		return v
+	}
+	return c.Context.Value(key)
+}
+
+// startSpan starts the span of a Client method
+func (c *Client) startSpan(ctx context.Context, method, endpoint string, attrs ...Attribute) (context.Context, Span) {
+	if c.tracer == nil {
+		return ctx, noopSpan{}
+	}
+	return c.tracer.Start(ctx, "intouchpay."+method, append([]Attribute{{Key: AttrEndpoint, Value: endpoint}}, attrs...)...)
+}
+
+// endSpan records the outcome of a Client method and ends its span
+func endSpan(span Span, code ResponseCode, err error) {
+	if code != "" {
+		span.SetAttributes(Attribute{Key: AttrResponseCode, Value: code.String()})
+	}
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// traceRequester starts a child span for every HTTP attempt
+type traceRequester struct {
+	next   Requester
+	tracer Tracer
+}
+
+// DoInto sends the request in a span named after the endpoint
+func (r *traceRequester) DoInto(ctx context.Context, endpoint string, body, out interface{}) error {
+	ctx, span := r.tracer.Start(ctx, "POST "+endpoint, Attribute{Key: AttrEndpoint, Value: endpoint})
+	err := r.next.DoInto(ctx, endpoint, body, out)
+
+	var apiErr *APIError
+	switch {
+	case errors.As(err, &apiErr):
+		span.SetAttributes(Attribute{Key: AttrHTTPStatus, Value: apiErr.StatusCode})
+	case err == nil:
+		span.SetAttributes(Attribute{Key: AttrHTTPStatus, Value: http.StatusOK})
+	}
+	code := ResponseCode("")
+	if err == nil {
+		code, _, _ = responseSummary(out)
+	}
+	endSpan(span, code, err)
+	return err
+}
@@ -0,0 +1,182 @@
+package Intouchpay_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+	"github.com/samueltuyizere/go-intouchpay/intouchpaytest"
+	"github.com/stretchr/testify/assert"
+)
+
+// RecordedSpan is a span kept in memory by RecordingTracer
+type RecordedSpan struct {
+	tracer *RecordingTracer
+	Name   string
+	Parent *RecordedSpan
+	Attrs  map[string]interface{}
+	Err    error
+	Ended  bool
+}
+
+func (s *RecordedSpan) SetAttributes(attrs ...Intouchpay.Attribute) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	for _, attr := range attrs {
+		s.Attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *RecordedSpan) RecordError(err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.Err = err
+}
+
+func (s *RecordedSpan) End() {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.Ended = true
+}
+
+// spanKey is the context key of the current RecordedSpan
+type spanKey struct{}
+
+// spanFrom returns the current span in ctx
+func spanFrom(ctx context.Context) *RecordedSpan {
+	if span, ok := ctx.Value(spanKey{}).(*RecordedSpan); ok {
+		return span
+	}
+	return nil
+}
+
+// spanOnly keeps only the current RecordedSpan of ctx, as a TraceLinks extract function
+func spanOnly(ctx context.Context) context.Context {
+	return context.WithValue(context.Background(), spanKey{}, spanFrom(ctx))
+}
+
+// callerKey is the context key of a request-scoped value set by the caller
+type callerKey struct{}
+
+// RecordingTracer implements Tracer by keeping every span in memory
+type RecordingTracer struct {
+	mu    sync.Mutex
+	Spans []*RecordedSpan
+}
+
+func (t *RecordingTracer) Start(ctx context.Context, name string, attrs ...Intouchpay.Attribute) (context.Context, Intouchpay.Span) {
+	span := &RecordedSpan{tracer: t, Name: name, Parent: spanFrom(ctx), Attrs: make(map[string]interface{})}
+	for _, attr := range attrs {
+		span.Attrs[attr.Key] = attr.Value
+	}
+	t.mu.Lock()
+	t.Spans = append(t.Spans, span)
+	t.mu.Unlock()
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Find returns the first span called name
+func (t *RecordingTracer) Find(name string) *RecordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, span := range t.Spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	return nil
+}
+
+// TestTracingPaymentAndCallback tests that a payment, its HTTP attempt and its callback form one trace
+func TestTracingPaymentAndCallback(t *testing.T) {
+	tracer := &RecordingTracer{}
+	links := Intouchpay.NewTraceLinks(0, spanOnly)
+	callbacks := httptest.NewServer(Intouchpay.NewCallbackHandler(func(ctx context.Context, _ *Intouchpay.CallbackEvent) error {
+		if span := spanFrom(ctx); span == nil || span.Name != "intouchpay.Callback" {
+			t.Error("callback context does not carry the callback span")
+		}
+		if ctx.Value(callerKey{}) != nil {
+			t.Error("the payment caller's context values were kept")
+		}
+		return ctx.Err()
+	}, Intouchpay.WithCallbackTracer(tracer, links)))
+	defer callbacks.Close()
+	server := intouchpaytest.NewServer()
+	defer server.Close()
+	client := server.NewClient(Intouchpay.WithCallbackURL(callbacks.URL), Intouchpay.WithTracer(tracer, links))
+
+	ctx := context.WithValue(context.Background(), callerKey{}, "request-scoped")
+	_, err := client.RequestPaymentContext(ctx, &Intouchpay.RequestPaymentParams{Amount: Intouchpay.RWF(500), MobilePhone: "0781234567", RequestTransactionID: "P1"})
+	assert.NoError(t, err)
+	assert.NoError(t, server.CompletePayment("P1", Intouchpay.CodeSuccessful))
+
+	payment := tracer.Find("intouchpay.RequestPayment")
+	assert.NotNil(t, payment)
+	assert.Nil(t, payment.Parent)
+	assert.True(t, payment.Ended)
+	assert.Equal(t, Intouchpay.RequestPaymentEndpoint, payment.Attrs[Intouchpay.AttrEndpoint])
+	assert.Equal(t, int64(500), payment.Attrs[Intouchpay.AttrAmount])
+	assert.Equal(t, "mtn", payment.Attrs[Intouchpay.AttrOperator])
+	assert.Equal(t, "P1", payment.Attrs[Intouchpay.AttrRequestTransactionID])
+	assert.Equal(t, "1000", payment.Attrs[Intouchpay.AttrResponseCode])
+
+	attempt := tracer.Find("POST " + Intouchpay.RequestPaymentEndpoint)
+	assert.NotNil(t, attempt)
+	assert.Same(t, payment, attempt.Parent)
+	assert.Equal(t, 200, attempt.Attrs[Intouchpay.AttrHTTPStatus])
+
+	callback := tracer.Find("intouchpay.Callback")
+	assert.NotNil(t, callback)
+	assert.Same(t, payment, callback.Parent)
+	assert.Equal(t, "01", callback.Attrs[Intouchpay.AttrResponseCode])
+	assert.NoError(t, callback.Err)
+}
+
+// TestTracingLinksOnlySentRequests tests that requests refused before or while sending leave
+// no trace link behind
+func TestTracingLinksOnlySentRequests(t *testing.T) {
+	tracer := &RecordingTracer{}
+	links := Intouchpay.NewTraceLinks(0, spanOnly)
+	handler := Intouchpay.NewCallbackHandler(func(context.Context, *Intouchpay.CallbackEvent) error { return nil },
+		Intouchpay.WithCallbackTracer(tracer, links))
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{},
+		Intouchpay.NewHTTPClient(&http.Client{Transport: &dialFailTransport{}}, "http://intouchpay.invalid"),
+		Intouchpay.WithTracer(tracer, links))
+
+	_, err := client.RequestPayment(&Intouchpay.RequestPaymentParams{Amount: Intouchpay.RWF(500), MobilePhone: "12", RequestTransactionID: "P1"})
+	assert.True(t, Intouchpay.IsValidationError(err))
+	_, err = client.RequestDeposit(&Intouchpay.RequestDepositParams{Amount: Intouchpay.RWF(500), MobilePhone: "0781234567", RequestTransactionID: "D1"})
+	assert.True(t, Intouchpay.IsRequestNotSent(err))
+
+	for _, id := range []string{"P1", "D1"} {
+		body := `{"jsonpayload":{"requesttransactionid":"` + id + `","transactionid":"T1","responsecode":"01","status":"Successfull","statusdesc":"Successfully Processed Transaction","referenceno":"REF"}}`
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/callback", strings.NewReader(body)))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+	for _, span := range tracer.Spans {
+		if span.Name == "intouchpay.Callback" {
+			assert.Nil(t, span.Parent, span.Attrs[Intouchpay.AttrRequestTransactionID])
+		}
+	}
+}
+
+// TestTracingRecordsErrors tests that failed calls are recorded on their spans
+func TestTracingRecordsErrors(t *testing.T) {
+	tracer := &RecordingTracer{}
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, &MockHTTPClient{Error: &Intouchpay.APIError{StatusCode: 503}},
+		Intouchpay.WithTracer(tracer, nil))
+
+	_, err := client.GetBalance()
+
+	assert.Error(t, err)
+	method := tracer.Find("intouchpay.GetBalance")
+	assert.Equal(t, err, method.Err)
+	attempt := tracer.Find("POST " + Intouchpay.GetBalanceEndpoint)
+	assert.Equal(t, 503, attempt.Attrs[Intouchpay.AttrHTTPStatus])
+	assert.True(t, attempt.Ended)
+}
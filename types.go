@@ -1,48 +1,92 @@
 package Intouchpay
 
-import "net/http"
+import (
+	"log/slog"
+	"net/http"
+)
 
 // Client represents an IntouchPay client configured with authentication details
 type Client struct {
-	Username        string // User name assigned to your account
-	AccountNo       string
+	Username  string // User name assigned to your account
+	AccountNo string
+	// Deprecated: the partner password is no longer kept on the client and this field is
+	// always empty. Use a CredentialProvider to supply it.
 	PartnerPassword string
 	CallbackURL     string
 	Sid             int          // Service ID. Set to 1 For Bulk Payments, can only be 0 or 1
 	HTTPClient      *http.Client // Kept for backward compatibility
 	auth            Authenticator
 	httpClient      APIRequester // Internal HTTP client interface
+	retryPolicy     *RetryPolicy
+	rateLimiter     *RateLimiter
+	breaker         *CircuitBreaker
+	middlewares     []Middleware
+	logger          *slog.Logger
+	metrics         MetricsRecorder
+	tracer          Tracer
+	traceLinks      *TraceLinks
+	store           TransactionStore
+	strictDecoding  bool
+	baseURL         string      // Base URL of the default requester, BaseURL when empty
+	environment     Environment // Set with WithEnvironment
+	guard           productionGuard
 }
 
 // FailedRequestResponse represents a failed API response
 type FailedRequestResponse struct {
-	Success      bool   `json:"success"`
-	ResponseCode string `json:"responsecode"`
-	Message      string `json:"message"`
+	Success      bool         `json:"success"`
+	ResponseCode ResponseCode `json:"responsecode"`
+	Message      string       `json:"message"`
+
+	ResponseMeta
+}
+
+// Code returns the typed response code
+func (r *FailedRequestResponse) Code() ResponseCode {
+	return r.ResponseCode.canonical()
+}
+
+// UnmarshalJSON decodes the response, keeping the raw body and unknown fields
+func (r *FailedRequestResponse) UnmarshalJSON(data []byte) error {
+	type plain FailedRequestResponse
+	return decodeResponse(data, (*plain)(r), &r.ResponseMeta)
 }
 
 // RequestPaymentParams represents parameters for RequestPayment
 type RequestPaymentParams struct {
-	Amount               uint   `json:"amount"` // Amount as a positive integer with no decimals
+	Amount               Money  `json:"amount"` // Whole francs, no decimals
 	MobilePhone          string `json:"mobilephone"`
 	RequestTransactionID string `json:"requesttransactionid"`
 }
 
 // RequestPaymentResponse represents the response from RequestPayment
 type RequestPaymentResponse struct {
-	Status               string `json:"status"`
-	RequestTransactionID string `json:"requesttransactionid"`
-	Success              bool   `json:"success"`
-	ResponseCode         string `json:"responsecode"`
-	TransactionID        string `json:"transactionid"`
-	Message              string `json:"message"`
+	Status               string       `json:"status"`
+	RequestTransactionID string       `json:"requesttransactionid"`
+	Success              bool         `json:"success"`
+	ResponseCode         ResponseCode `json:"responsecode"`
+	TransactionID        string       `json:"transactionid"`
+	Message              string       `json:"message"`
+
+	ResponseMeta
+}
+
+// Code returns the typed response code
+func (r *RequestPaymentResponse) Code() ResponseCode {
+	return r.ResponseCode.canonical()
+}
+
+// UnmarshalJSON decodes the response, keeping the raw body and unknown fields
+func (r *RequestPaymentResponse) UnmarshalJSON(data []byte) error {
+	type plain RequestPaymentResponse
+	return decodeResponse(data, (*plain)(r), &r.ResponseMeta)
 }
 
 // RequestPaymentBody represents the request body for RequestPayment
 type RequestPaymentBody struct {
 	Username             string `json:"username"`
 	Timestamp            string `json:"timestamp"`
-	Amount               uint   `json:"amount"`
+	Amount               Money  `json:"amount"`
 	Password             string `json:"password"`
 	MobilePhone          string `json:"mobilephone"`
 	RequestTransactionID string `json:"requesttransactionid"`
@@ -54,7 +98,7 @@ type RequestPaymentBody struct {
 type RequestDepositBody struct {
 	Username             string `json:"username"`
 	Timestamp            string `json:"timestamp"`
-	Amount               uint   `json:"amount"`
+	Amount               Money  `json:"amount"`
 	WithdrawCharge       int    `json:"withdrawcharge"`
 	Reason               string `json:"reason"`
 	Sid                  int    `json:"sid"`
@@ -83,15 +127,28 @@ type GetTransactionStatusBody struct {
 
 // BalanceResponse represents the response from GetBalance
 type BalanceResponse struct {
-	Balance      float64 `json:"balance"`
-	Success      bool    `json:"success"`
-	ResponseCode int     `json:"responsecode,omitempty"`
-	Message      string  `json:"message,omitempty"`
+	Balance      Money        `json:"balance"`
+	Success      bool         `json:"success"`
+	ResponseCode ResponseCode `json:"responsecode,omitempty"`
+	Message      string       `json:"message,omitempty"`
+
+	ResponseMeta
+}
+
+// Code returns the typed response code, or an empty code if none was returned
+func (r *BalanceResponse) Code() ResponseCode {
+	return r.ResponseCode.canonical()
+}
+
+// UnmarshalJSON decodes the response, keeping the raw body and unknown fields
+func (r *BalanceResponse) UnmarshalJSON(data []byte) error {
+	type plain BalanceResponse
+	return decodeResponse(data, (*plain)(r), &r.ResponseMeta)
 }
 
 // RequestDepositParams represents parameters for RequestDeposit
 type RequestDepositParams struct {
-	Amount               uint   `json:"amount"`         // Amount as a positive integer with no decimals
+	Amount               Money  `json:"amount"`         // Whole francs, no decimals
 	WithdrawCharge       int    `json:"withdrawcharge"` // Set to 1 to include Withdraw Charges in amount sent to subscriber
 	Reason               string `json:"reason"`
 	MobilePhone          string `json:"mobilephone"`
@@ -100,10 +157,23 @@ type RequestDepositParams struct {
 
 // RequestDepositResponse represents the response from RequestDeposit
 type RequestDepositResponse struct {
-	RequestTransactionID string `json:"requesttransactionid"`
-	ReferenceID          string `json:"referenceid,omitempty"` // Only returned if successful
-	ResponseCode         string `json:"responsecode"`
-	Success              bool   `json:"success"`
+	RequestTransactionID string       `json:"requesttransactionid"`
+	ReferenceID          string       `json:"referenceid,omitempty"` // Only returned if successful
+	ResponseCode         ResponseCode `json:"responsecode"`
+	Success              bool         `json:"success"`
+
+	ResponseMeta
+}
+
+// Code returns the typed response code
+func (r *RequestDepositResponse) Code() ResponseCode {
+	return r.ResponseCode.canonical()
+}
+
+// UnmarshalJSON decodes the response, keeping the raw body and unknown fields
+func (r *RequestDepositResponse) UnmarshalJSON(data []byte) error {
+	type plain RequestDepositResponse
+	return decodeResponse(data, (*plain)(r), &r.ResponseMeta)
 }
 
 // GetTransactionStatusParams represents parameters for GetTransactionStatus
@@ -114,8 +184,21 @@ type GetTransactionStatusParams struct {
 
 // GetTransactionStatusResponse represents the response from GetTransactionStatus
 type GetTransactionStatusResponse struct {
-	Success      bool   `json:"success"`
-	ResponseCode int    `json:"responsecode"`
-	Status       string `json:"status,omitempty"`
-	Message      string `json:"message"`
+	Success      bool         `json:"success"`
+	ResponseCode ResponseCode `json:"responsecode"`
+	Status       string       `json:"status,omitempty"`
+	Message      string       `json:"message"`
+
+	ResponseMeta
+}
+
+// Code returns the typed response code
+func (r *GetTransactionStatusResponse) Code() ResponseCode {
+	return r.ResponseCode.canonical()
+}
+
+// UnmarshalJSON decodes the response, keeping the raw body and unknown fields
+func (r *GetTransactionStatusResponse) UnmarshalJSON(data []byte) error {
+	type plain GetTransactionStatusResponse
+	return decodeResponse(data, (*plain)(r), &r.ResponseMeta)
 }
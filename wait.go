@@ -0,0 +1,91 @@
+package Intouchpay
+
+import (
+	"context"
+	"time"
+)
+
+// WaitOptions configures how WaitForFinalStatus polls GetTransactionStatus
+type WaitOptions struct {
+	InitialInterval time.Duration // Delay between the first polls, 2s if zero; the first poll is immediate
+	MaxInterval     time.Duration // Upper bound for the delay between polls, 30s if zero
+	Multiplier      float64       // Growth factor between delays, 1.5 if zero
+}
+
+// StatusObservation records one poll made by WaitForFinalStatus
+type StatusObservation struct {
+	At      time.Time
+	Code    ResponseCode
+	Status  string
+	Message string
+	Err     error // Set when the status query itself failed
+}
+
+// withDefaults returns a copy of o with zero fields replaced by their defaults
+func (o *WaitOptions) withDefaults() WaitOptions {
+	opts := WaitOptions{}
+	if o != nil {
+		opts = *o
+	}
+	if opts.InitialInterval <= 0 {
+		opts.InitialInterval = 2 * time.Second
+	}
+	if opts.MaxInterval <= 0 {
+		opts.MaxInterval = 30 * time.Second
+	}
+	if opts.Multiplier <= 0 {
+		opts.Multiplier = 1.5
+	}
+	return opts
+}
+
+// WaitForFinalStatus polls GetTransactionStatus until the transaction reaches a terminal state
+// (success or any failure class) or ctx is done. It returns the last status response together
+// with every state observed along the way. Status queries that fail with a retryable error are
+// recorded in the history and polling continues; other errors are returned immediately.
+// When ctx ends first the last response seen, if any, is returned with ctx.Err().
+func (c *Client) WaitForFinalStatus(ctx context.Context, params *GetTransactionStatusParams, opts *WaitOptions) (*GetTransactionStatusResponse, []StatusObservation, error) {
+	o := opts.withDefaults()
+	var history []StatusObservation
+	var last *GetTransactionStatusResponse
+	interval := o.InitialInterval
+
+	for {
+		resp, err := c.GetTransactionStatusContext(ctx, params)
+		observation := StatusObservation{At: time.Now(), Err: err}
+		if resp != nil {
+			observation.Code = resp.Code()
+			observation.Status = resp.Status
+			observation.Message = resp.Message
+		}
+		history = append(history, observation)
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return last, history, ctx.Err()
+			}
+			if !DefaultRetryable(GetTransactionStatusEndpoint, err) {
+				return resp, history, err
+			}
+		} else {
+			last = resp
+			class := resp.Code().Class()
+			if class == ClassSuccess || class.IsFailure() {
+				return resp, history, nil
+			}
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return last, history, ctx.Err()
+		case <-timer.C:
+		}
+
+		interval = time.Duration(float64(interval) * o.Multiplier)
+		if interval > o.MaxInterval {
+			interval = o.MaxInterval
+		}
+	}
+}
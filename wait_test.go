@@ -0,0 +1,105 @@
+package Intouchpay_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	Intouchpay "github.com/samueltuyizere/go-intouchpay"
+	"github.com/stretchr/testify/assert"
+)
+
+// SequenceHTTPClient implements APIRequester by replaying a list of results
+type SequenceHTTPClient struct {
+	Responses []*map[string]interface{}
+	Errors    []error
+	Calls     int
+}
+
+func (s *SequenceHTTPClient) Do(_ string, _ interface{}) (*map[string]interface{}, error) {
+	i := s.Calls
+	if i >= len(s.Responses) {
+		i = len(s.Responses) - 1
+	}
+	s.Calls++
+	var err error
+	if i < len(s.Errors) {
+		err = s.Errors[i]
+	}
+	return s.Responses[i], err
+}
+
+// fastWaitOptions returns polling options with negligible delays
+func fastWaitOptions() *Intouchpay.WaitOptions {
+	return &Intouchpay.WaitOptions{InitialInterval: time.Millisecond, MaxInterval: 2 * time.Millisecond}
+}
+
+// TestWaitForFinalStatusSuccess tests polling until the transaction succeeds
+func TestWaitForFinalStatusSuccess(t *testing.T) {
+	pending := &map[string]interface{}{"success": true, "responsecode": 1000, "status": "Pending"}
+	done := &map[string]interface{}{"success": true, "responsecode": 1, "status": "Successfull"}
+	requester := &SequenceHTTPClient{
+		Responses: []*map[string]interface{}{pending, nil, pending, done},
+		Errors:    []error{nil, &Intouchpay.APIError{StatusCode: http.StatusBadGateway}},
+	}
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, requester)
+
+	resp, history, err := client.WaitForFinalStatus(context.Background(), &Intouchpay.GetTransactionStatusParams{
+		RequestTransactionID: "TX1",
+	}, fastWaitOptions())
+
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.CodeSuccessful, resp.Code())
+	assert.Len(t, history, 4)
+	assert.Equal(t, Intouchpay.CodePending, history[0].Code)
+	assert.Error(t, history[1].Err)
+	assert.Equal(t, "Successfull", history[3].Status)
+}
+
+// TestWaitForFinalStatusFailure tests that a failure code is terminal
+func TestWaitForFinalStatusFailure(t *testing.T) {
+	requester := &SequenceHTTPClient{Responses: []*map[string]interface{}{
+		{"success": false, "responsecode": 3100, "message": "Transaction Doesn't Exist"},
+	}}
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, requester)
+
+	resp, history, err := client.WaitForFinalStatus(context.Background(), &Intouchpay.GetTransactionStatusParams{}, fastWaitOptions())
+
+	assert.NoError(t, err)
+	assert.Equal(t, Intouchpay.CodeTransactionNotFound, resp.Code())
+	assert.Len(t, history, 1)
+	assert.Equal(t, 1, requester.Calls)
+}
+
+// TestWaitForFinalStatusContextExpires tests that polling stops when the context ends
+func TestWaitForFinalStatusContextExpires(t *testing.T) {
+	requester := &SequenceHTTPClient{Responses: []*map[string]interface{}{
+		{"success": true, "responsecode": 1000, "status": "Pending"},
+	}}
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, requester)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	resp, history, err := client.WaitForFinalStatus(ctx, &Intouchpay.GetTransactionStatusParams{}, fastWaitOptions())
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, Intouchpay.CodePending, resp.Code())
+	assert.NotEmpty(t, history)
+}
+
+// TestWaitForFinalStatusPermanentError tests that non-retryable errors are returned
+func TestWaitForFinalStatusPermanentError(t *testing.T) {
+	requester := &SequenceHTTPClient{
+		Responses: []*map[string]interface{}{nil},
+		Errors:    []error{errors.New("boom")},
+	}
+	client := Intouchpay.NewClientWithHTTPClient(&MockAuthenticator{}, requester)
+
+	_, history, err := client.WaitForFinalStatus(context.Background(), &Intouchpay.GetTransactionStatusParams{}, nil)
+
+	assert.EqualError(t, err, "boom")
+	assert.Len(t, history, 1)
+}